@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+const (
+	gptHeaderLBA          = 1
+	gptPartitionEntries   = 128
+	gptPartitionEntrySize = 128
+)
+
+// Disk is an in-memory representation of a disk's partition layout, sufficient to round-trip through
+// WriteGPT and to be built up via AddPartition/DeletePartition/ResizePartition before being written out.
+type Disk struct {
+	BlockSize  uint32
+	NumBlocks  uint64
+	DiskGUID   [16]byte
+	Partitions []GPTPartition
+}
+
+// NewDisk creates an empty Disk of the given geometry with a freshly generated DiskGUID. numBlocks must
+// be large enough to hold the protective MBR plus the primary and backup GPT headers and entry tables;
+// anything smaller is rejected here instead of underflowing lastUsableLBA later.
+func NewDisk(blockSize uint32, numBlocks uint64) (*Disk, error) {
+	d := &Disk{BlockSize: blockSize, NumBlocks: numBlocks}
+	if minBlocks := 2*(1+d.entryTableBlocks()) + 1; numBlocks < minBlocks {
+		return nil, fmt.Errorf("numBlocks %d too small for GPT overhead: need at least %d blocks (protective MBR plus primary/backup headers and entry tables) at %d bytes/block", numBlocks, minBlocks, blockSize)
+	}
+
+	guid, err := randomGUID()
+	if err != nil {
+		return nil, err
+	}
+	d.DiskGUID = guid
+	return d, nil
+}
+
+func randomGUID() ([16]byte, error) {
+	var g [16]byte
+	if _, err := rand.Read(g[:]); err != nil {
+		return g, fmt.Errorf("generating GUID: %w", err)
+	}
+	return g, nil
+}
+
+// entryTableBlocks returns how many blocks the 128-entry partition array occupies.
+func (d *Disk) entryTableBlocks() uint64 {
+	return uint64(gptPartitionEntries*gptPartitionEntrySize) / uint64(d.BlockSize)
+}
+
+func (d *Disk) firstUsableLBA() uint64 {
+	return 2 + d.entryTableBlocks()
+}
+
+func (d *Disk) lastUsableLBA() uint64 {
+	return d.NumBlocks - 2 - d.entryTableBlocks()
+}
+
+func (d *Disk) overlapsExisting(skip int, startLBA, endLBA uint64) (int, bool) {
+	for i, p := range d.Partitions {
+		if i == skip {
+			continue
+		}
+		if startLBA <= p.EndLBA && p.StartLBA <= endLBA {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// AddPartition appends a new partition spanning [startLBA, endLBA], validating that it falls within the
+// usable LBA range and does not overlap any existing partition. A random PartitionGUID is generated for it.
+func (d *Disk) AddPartition(typeGUID [16]byte, name string, startLBA, endLBA uint64) (*GPTPartition, error) {
+	if startLBA > endLBA {
+		return nil, fmt.Errorf("start LBA %d is after end LBA %d", startLBA, endLBA)
+	}
+	if startLBA < d.firstUsableLBA() || endLBA > d.lastUsableLBA() {
+		return nil, fmt.Errorf("partition range %d-%d outside usable range %d-%d", startLBA, endLBA, d.firstUsableLBA(), d.lastUsableLBA())
+	}
+	if i, ok := d.overlapsExisting(-1, startLBA, endLBA); ok {
+		return nil, fmt.Errorf("partition range %d-%d overlaps existing partition %d (%d-%d)", startLBA, endLBA, i+1, d.Partitions[i].StartLBA, d.Partitions[i].EndLBA)
+	}
+
+	partGUID, err := randomGUID()
+	if err != nil {
+		return nil, err
+	}
+
+	p := GPTPartition{
+		TypeGUID:      typeGUID,
+		PartitionGUID: partGUID,
+		StartLBA:      startLBA,
+		EndLBA:        endLBA,
+	}
+	copy(p.Name[:], encodeUTF16LEName(name))
+	d.Partitions = append(d.Partitions, p)
+	return &d.Partitions[len(d.Partitions)-1], nil
+}
+
+// DeletePartition removes the partition at the given 0-based index.
+func (d *Disk) DeletePartition(index int) error {
+	if index < 0 || index >= len(d.Partitions) {
+		return fmt.Errorf("partition index %d out of range", index)
+	}
+	d.Partitions = append(d.Partitions[:index], d.Partitions[index+1:]...)
+	return nil
+}
+
+// ResizePartition changes the end LBA of the partition at the given 0-based index, validating that it still
+// fits within the usable range and does not overlap any other partition.
+func (d *Disk) ResizePartition(index int, newEndLBA uint64) error {
+	if index < 0 || index >= len(d.Partitions) {
+		return fmt.Errorf("partition index %d out of range", index)
+	}
+	p := d.Partitions[index]
+	if newEndLBA < p.StartLBA || newEndLBA > d.lastUsableLBA() {
+		return fmt.Errorf("new end LBA %d invalid for partition starting at %d", newEndLBA, p.StartLBA)
+	}
+	if i, ok := d.overlapsExisting(index, p.StartLBA, newEndLBA); ok {
+		return fmt.Errorf("resize to end LBA %d overlaps partition %d (%d-%d)", newEndLBA, i+1, d.Partitions[i].StartLBA, d.Partitions[i].EndLBA)
+	}
+	d.Partitions[index].EndLBA = newEndLBA
+	return nil
+}
+
+func encodeUTF16LEName(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[i*2:i*2+2], u)
+	}
+	return buf
+}
+
+// encodeGPTHeader serializes a GPTHeader into a SECTOR_SIZE-sized buffer laid out per the UEFI spec.
+func encodeGPTHeader(h GPTHeader) []byte {
+	buf := make([]byte, SECTOR_SIZE)
+	copy(buf[0:8], h.Signature[:])
+	binary.LittleEndian.PutUint32(buf[8:12], h.Revision)
+	binary.LittleEndian.PutUint32(buf[12:16], h.HeaderSize)
+	binary.LittleEndian.PutUint32(buf[16:20], h.HeaderCRC32)
+	binary.LittleEndian.PutUint32(buf[20:24], h.Reserved)
+	binary.LittleEndian.PutUint64(buf[24:32], h.CurrentLBA)
+	binary.LittleEndian.PutUint64(buf[32:40], h.BackupLBA)
+	binary.LittleEndian.PutUint64(buf[40:48], h.FirstUsableLBA)
+	binary.LittleEndian.PutUint64(buf[48:56], h.LastUsableLBA)
+	copy(buf[56:72], h.DiskGUID[:])
+	binary.LittleEndian.PutUint64(buf[72:80], h.PartitionTableLBA)
+	binary.LittleEndian.PutUint32(buf[80:84], h.NumPartitions)
+	binary.LittleEndian.PutUint32(buf[84:88], h.PartitionEntrySize)
+	binary.LittleEndian.PutUint32(buf[88:92], h.PartitionTableCRC)
+	return buf
+}
+
+// encodeGPTPartitionEntry serializes a GPTPartition into its raw 128-byte on-disk form.
+func encodeGPTPartitionEntry(p GPTPartition) []byte {
+	buf := make([]byte, gptPartitionEntrySize)
+	copy(buf[0:16], p.TypeGUID[:])
+	copy(buf[16:32], p.PartitionGUID[:])
+	binary.LittleEndian.PutUint64(buf[32:40], p.StartLBA)
+	binary.LittleEndian.PutUint64(buf[40:48], p.EndLBA)
+	binary.LittleEndian.PutUint64(buf[48:56], p.Attributes)
+	copy(buf[56:128], p.Name[:])
+	return buf
+}
+
+// WriteMBR writes a legacy MBR sector with the given four partition entries.
+func WriteMBR(w io.WriterAt, entries [4]MBRPartition) error {
+	sector := make([]byte, SECTOR_SIZE)
+	for i, e := range entries {
+		off := 446 + i*16
+		sector[off] = e.Status
+		copy(sector[off+1:off+4], e.StartCHS[:])
+		sector[off+4] = e.Type
+		copy(sector[off+5:off+8], e.EndCHS[:])
+		binary.LittleEndian.PutUint32(sector[off+8:off+12], e.StartLBA)
+		binary.LittleEndian.PutUint32(sector[off+12:off+16], e.SizeBlocks)
+	}
+	binary.LittleEndian.PutUint16(sector[510:512], MBR_SIGNATURE)
+	_, err := w.WriteAt(sector, 0)
+	return err
+}
+
+// WriteProtectiveMBR writes a single protective 0xEE MBR entry covering the whole disk, with the size
+// capped at the 32-bit LBA maximum as required by the UEFI spec.
+func WriteProtectiveMBR(w io.WriterAt, blockSize uint32, numBlocks uint64) error {
+	sizeBlocks := numBlocks - 1
+	if sizeBlocks > 0xFFFFFFFF {
+		sizeBlocks = 0xFFFFFFFF
+	}
+	entries := [4]MBRPartition{{
+		Status:     0x00,
+		StartCHS:   [3]uint8{0x00, 0x02, 0x00},
+		Type:       0xEE,
+		EndCHS:     [3]uint8{0xFF, 0xFF, 0xFF},
+		StartLBA:   1,
+		SizeBlocks: uint32(sizeBlocks),
+	}}
+	return WriteMBR(w, entries)
+}
+
+// WriteGPT writes a protective MBR plus mirrored primary and backup GPT headers and partition arrays for d,
+// computing all four CRC32s (header/table, primary/backup) along the way.
+func WriteGPT(w io.WriterAt, d *Disk) error {
+	if err := WriteProtectiveMBR(w, d.BlockSize, d.NumBlocks); err != nil {
+		return err
+	}
+
+	backupEntryTableLBA := d.NumBlocks - 1 - d.entryTableBlocks()
+	backupHeaderLBA := d.NumBlocks - 1
+
+	entryBytes := make([]byte, gptPartitionEntries*gptPartitionEntrySize)
+	for i, p := range d.Partitions {
+		copy(entryBytes[i*gptPartitionEntrySize:(i+1)*gptPartitionEntrySize], encodeGPTPartitionEntry(p))
+	}
+	tableCRC := gptTableCRC32(entryBytes)
+
+	primary := GPTHeader{
+		Signature:          [8]byte{'E', 'F', 'I', ' ', 'P', 'A', 'R', 'T'},
+		Revision:           0x00010000,
+		HeaderSize:         92,
+		CurrentLBA:         gptHeaderLBA,
+		BackupLBA:          backupHeaderLBA,
+		FirstUsableLBA:     d.firstUsableLBA(),
+		LastUsableLBA:      d.lastUsableLBA(),
+		DiskGUID:           d.DiskGUID,
+		PartitionTableLBA:  2,
+		NumPartitions:      gptPartitionEntries,
+		PartitionEntrySize: gptPartitionEntrySize,
+		PartitionTableCRC:  tableCRC,
+	}
+	primary.HeaderCRC32 = gptHeaderCRC32(encodeGPTHeader(primary), primary.HeaderSize)
+
+	backup := primary
+	backup.CurrentLBA = backupHeaderLBA
+	backup.BackupLBA = gptHeaderLBA
+	backup.PartitionTableLBA = backupEntryTableLBA
+	backup.HeaderCRC32 = gptHeaderCRC32(encodeGPTHeader(backup), backup.HeaderSize)
+
+	blockSize := int64(d.BlockSize)
+	if _, err := w.WriteAt(entryBytes, 2*blockSize); err != nil {
+		return fmt.Errorf("writing primary partition table: %w", err)
+	}
+	if _, err := w.WriteAt(encodeGPTHeader(primary), gptHeaderLBA*blockSize); err != nil {
+		return fmt.Errorf("writing primary header: %w", err)
+	}
+	if _, err := w.WriteAt(entryBytes, int64(backupEntryTableLBA)*blockSize); err != nil {
+		return fmt.Errorf("writing backup partition table: %w", err)
+	}
+	if _, err := w.WriteAt(encodeGPTHeader(backup), int64(backupHeaderLBA)*blockSize); err != nil {
+		return fmt.Errorf("writing backup header: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	fptTag        = "$FPT"
+	fptHeaderSize = 32
+	fptEntrySize  = 32
+
+	// fptScanWindow bounds how much of an image is read to search for the "$FPT" marker. ME firmware
+	// dumps carry it well within the first few megabytes, so there's no need to slurp a multi-GB image
+	// that simply isn't one.
+	fptScanWindow = 32 * 1024 * 1024
+)
+
+// FPTHeader is the legacy Intel Management Engine Flash Partition Table header (32 bytes).
+type FPTHeader struct {
+	Tag            [4]byte
+	NumEntries     uint32
+	HeaderVersion  uint8
+	EntryVersion   uint8
+	HeaderLength   uint8
+	HeaderChecksum uint8
+	TicksToAdd     uint16
+	TokensToAdd    uint16
+	UMASize        uint32
+	Flags          uint32
+	FitcMajor      uint16
+	FitcMinor      uint16
+	FitcHotfix     uint16
+	FitcBuild      uint16
+}
+
+// FPTEntry is a single 32-byte entry in the Flash Partition Table.
+type FPTEntry struct {
+	Name           [4]byte
+	Owner          uint32
+	Offset         uint32
+	Length         uint32
+	StartTokens    uint32
+	MaxTokens      uint32
+	ScratchSectors uint32
+	Flags          uint32
+}
+
+// fptPartitionNames maps known FPT partition name tags to human-readable descriptions.
+var fptPartitionNames = map[string]string{
+	"FTPR": "Main ME firmware code",
+	"NFTP": "Additional ME firmware code",
+	"FTUP": "Full ME firmware update region",
+	"MDMV": "Modem firmware",
+	"UEPB": "Unified extension BIOS partition",
+	"DLMP": "IDLM debug partition",
+	"PSVN": "Partition table version",
+	"MFS":  "ME flash file system",
+	"ISHC": "Integrated Sensor Hub code",
+	"IUNP": "Innovation Engine unpacked partition",
+	"ROMB": "ROM bypass",
+	"FLOG": "Flash log",
+	"GLUT": "Global lookup table",
+}
+
+func fptEntryDescription(name string) string {
+	if desc, ok := fptPartitionNames[name]; ok {
+		return desc
+	}
+	return "Unknown"
+}
+
+// findFPT scans data for the "$FPT" marker, returning its byte offset. The marker conventionally follows
+// 16 bytes of padding at the very start of a legacy ME firmware image, but the whole buffer is searched in
+// case it's embedded further into a larger flash dump.
+func findFPT(data []byte) (int, bool) {
+	for i := 0; i+4 <= len(data); i++ {
+		if string(data[i:i+4]) == fptTag {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseFPTHeader parses the 32-byte FPT header starting at offset.
+func parseFPTHeader(data []byte, offset int) (FPTHeader, error) {
+	if offset+fptHeaderSize > len(data) {
+		return FPTHeader{}, fmt.Errorf("not enough data to read FPT header at offset %d", offset)
+	}
+	h := data[offset : offset+fptHeaderSize]
+	var header FPTHeader
+	copy(header.Tag[:], h[0:4])
+	header.NumEntries = binary.LittleEndian.Uint32(h[4:8])
+	header.HeaderVersion = h[8]
+	header.EntryVersion = h[9]
+	header.HeaderLength = h[10]
+	header.HeaderChecksum = h[11]
+	header.TicksToAdd = binary.LittleEndian.Uint16(h[12:14])
+	header.TokensToAdd = binary.LittleEndian.Uint16(h[14:16])
+	header.UMASize = binary.LittleEndian.Uint32(h[16:20])
+	header.Flags = binary.LittleEndian.Uint32(h[20:24])
+	header.FitcMajor = binary.LittleEndian.Uint16(h[24:26])
+	header.FitcMinor = binary.LittleEndian.Uint16(h[26:28])
+	header.FitcHotfix = binary.LittleEndian.Uint16(h[28:30])
+	header.FitcBuild = binary.LittleEndian.Uint16(h[30:32])
+	return header, nil
+}
+
+// fptHeaderChecksumValid reports whether the sum of the header's raw bytes is zero mod 256, which is how
+// Intel's $FPT header self-validates (the checksum byte is chosen so the total sum wraps to zero).
+func fptHeaderChecksumValid(data []byte, offset int) bool {
+	if offset+fptHeaderSize > len(data) {
+		return false
+	}
+	var sum uint8
+	for _, b := range data[offset : offset+fptHeaderSize] {
+		sum += b
+	}
+	return sum == 0
+}
+
+// parseFPTEntries parses the entry array immediately following the FPT header.
+func parseFPTEntries(data []byte, offset int, header FPTHeader) ([]FPTEntry, error) {
+	entriesOffset := offset + fptHeaderSize
+	need := int(header.NumEntries) * fptEntrySize
+	if need < 0 || entriesOffset+need > len(data) {
+		return nil, fmt.Errorf("not enough data to read %d FPT entries", header.NumEntries)
+	}
+	entries := make([]FPTEntry, header.NumEntries)
+	for i := range entries {
+		e := data[entriesOffset+i*fptEntrySize : entriesOffset+(i+1)*fptEntrySize]
+		copy(entries[i].Name[:], e[0:4])
+		entries[i].Owner = binary.LittleEndian.Uint32(e[4:8])
+		entries[i].Offset = binary.LittleEndian.Uint32(e[8:12])
+		entries[i].Length = binary.LittleEndian.Uint32(e[12:16])
+		entries[i].StartTokens = binary.LittleEndian.Uint32(e[16:20])
+		entries[i].MaxTokens = binary.LittleEndian.Uint32(e[20:24])
+		entries[i].ScratchSectors = binary.LittleEndian.Uint32(e[24:28])
+		entries[i].Flags = binary.LittleEndian.Uint32(e[28:32])
+	}
+	return entries, nil
+}
+
+// fptEntryName trims the trailing NUL padding from a raw 4-byte FPT entry name.
+func fptEntryName(raw [4]byte) string {
+	return trimNullString(raw[:])
+}
+
+// FPTInfo is the platform-independent result of parsing an Intel ME Flash Partition Table, shared by the
+// CLI and WASM front ends.
+type FPTInfo struct {
+	Offset        int            `json:"offset"`
+	NumEntries    uint32         `json:"numEntries"`
+	HeaderVersion uint8          `json:"headerVersion"`
+	EntryVersion  uint8          `json:"entryVersion"`
+	ChecksumValid bool           `json:"checksumValid"`
+	UMASize       uint32         `json:"umaSize"`
+	Flags         uint32         `json:"flags"`
+	Entries       []FPTEntryInfo `json:"entries"`
+}
+
+// FPTEntryInfo describes a single Flash Partition Table entry for display.
+type FPTEntryInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Offset      uint32 `json:"offset"`
+	Length      uint32 `json:"length"`
+}
+
+// parseFPT locates and parses the Flash Partition Table in data, returning nil if no "$FPT" marker is
+// present.
+func parseFPT(data []byte) (*FPTInfo, error) {
+	offset, ok := findFPT(data)
+	if !ok {
+		return nil, nil
+	}
+
+	header, err := parseFPTHeader(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseFPTEntries(data, offset, header)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &FPTInfo{
+		Offset:        offset,
+		NumEntries:    header.NumEntries,
+		HeaderVersion: header.HeaderVersion,
+		EntryVersion:  header.EntryVersion,
+		ChecksumValid: fptHeaderChecksumValid(data, offset),
+		UMASize:       header.UMASize,
+		Flags:         header.Flags,
+	}
+	for _, e := range entries {
+		name := fptEntryName(e.Name)
+		info.Entries = append(info.Entries, FPTEntryInfo{
+			Name:        name,
+			Description: fptEntryDescription(name),
+			Offset:      e.Offset,
+			Length:      e.Length,
+		})
+	}
+	return info, nil
+}
@@ -0,0 +1,14 @@
+package main
+
+// detectAPFS recognizes an APFS container by the "NXSB" magic at byte offset 32 of its block-zero
+// container superblock.
+func detectAPFS(data []byte) (FSInfo, bool) {
+	const magicOffset = 32
+	if len(data) < magicOffset+4 || string(data[magicOffset:magicOffset+4]) != "NXSB" {
+		return FSInfo{}, false
+	}
+	return FSInfo{
+		Type:           "APFS",
+		BytesPerSector: 4096,
+	}, true
+}
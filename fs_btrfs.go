@@ -0,0 +1,25 @@
+package main
+
+// detectBtrfs recognizes btrfs by the "_BHRfS_M" magic in its primary superblock at byte offset 0x10040
+// and reads the filesystem UUID and volume label.
+func detectBtrfs(data []byte) (FSInfo, bool) {
+	const (
+		superblockOffset = 0x10000
+		magicOffset      = superblockOffset + 0x40
+		fsidOffset       = superblockOffset + 0x20
+		labelOffset      = superblockOffset + 0x12B
+		labelSize        = 256
+	)
+	if len(data) < labelOffset+labelSize {
+		return FSInfo{}, false
+	}
+	if string(data[magicOffset:magicOffset+8]) != "_BHRfS_M" {
+		return FSInfo{}, false
+	}
+	return FSInfo{
+		Type:           "btrfs",
+		UUID:           formatUUIDBytes(data[fsidOffset : fsidOffset+16]),
+		Label:          trimNullString(data[labelOffset : labelOffset+labelSize]),
+		BytesPerSector: 4096,
+	}, true
+}
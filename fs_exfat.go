@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// detectExFAT recognizes the exFAT boot sector by its fixed "EXFAT   " OEM name and reads the volume
+// serial number and sector size.
+func detectExFAT(data []byte) (FSInfo, bool) {
+	if len(data) < 512 || string(data[3:11]) != "EXFAT   " {
+		return FSInfo{}, false
+	}
+	bytesPerSectorShift := data[108]
+	if bytesPerSectorShift > 15 {
+		return FSInfo{}, false
+	}
+	return FSInfo{
+		Type:           "exFAT",
+		UUID:           fmt.Sprintf("%08X", binary.LittleEndian.Uint32(data[100:104])),
+		BytesPerSector: uint16(1) << bytesPerSectorShift,
+	}, true
+}
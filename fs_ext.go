@@ -0,0 +1,31 @@
+package main
+
+import "encoding/binary"
+
+// detectExt recognizes ext2/ext3/ext4 by the superblock's 0xEF53 magic at byte offset 1080 (1024 + 56)
+// and reads the filesystem UUID and volume label.
+func detectExt(data []byte) (FSInfo, bool) {
+	const superblockOffset = 1024
+	if len(data) < superblockOffset+136 {
+		return FSInfo{}, false
+	}
+	sb := data[superblockOffset:]
+	if binary.LittleEndian.Uint16(sb[56:58]) != 0xEF53 {
+		return FSInfo{}, false
+	}
+
+	fsType := "ext2"
+	if binary.LittleEndian.Uint32(sb[92:96])&0x0004 != 0 { // s_feature_compat: COMPAT_HAS_JOURNAL
+		fsType = "ext3"
+	}
+	if binary.LittleEndian.Uint32(sb[96:100])&0x0040 != 0 { // s_feature_incompat: INCOMPAT_EXTENTS
+		fsType = "ext4"
+	}
+
+	return FSInfo{
+		Type:           fsType,
+		Label:          trimNullString(sb[120:136]),
+		UUID:           formatUUIDBytes(sb[104:120]),
+		BytesPerSector: 512,
+	}, true
+}
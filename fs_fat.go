@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// detectFAT recognizes FAT12/FAT16/FAT32 boot sectors via their BIOS Parameter Block and reads the
+// embedded volume label and serial number.
+func detectFAT(data []byte) (FSInfo, bool) {
+	if len(data) < 512 || data[510] != 0x55 || data[511] != 0xAA {
+		return FSInfo{}, false
+	}
+
+	bytesPerSector := binary.LittleEndian.Uint16(data[11:13])
+	if bytesPerSector == 0 {
+		return FSInfo{}, false
+	}
+	fatSize16 := binary.LittleEndian.Uint16(data[22:24])
+
+	if fatSize16 != 0 {
+		// FAT12/FAT16: extended BPB starts at offset 36.
+		if data[38] != 0x28 && data[38] != 0x29 {
+			return FSInfo{}, false
+		}
+		return FSInfo{
+			Type:           "FAT12/FAT16",
+			Label:          trimTrailingSpaces(data[43:54]),
+			UUID:           formatFATSerial(binary.LittleEndian.Uint32(data[39:43])),
+			BytesPerSector: bytesPerSector,
+		}, true
+	}
+
+	// FAT32: extended BPB starts at offset 36, pushing the driveNumber/bootSignature/label block to 64.
+	if len(data) < 90 || (data[66] != 0x28 && data[66] != 0x29) {
+		return FSInfo{}, false
+	}
+	return FSInfo{
+		Type:           "FAT32",
+		Label:          trimTrailingSpaces(data[71:82]),
+		UUID:           formatFATSerial(binary.LittleEndian.Uint32(data[67:71])),
+		BytesPerSector: bytesPerSector,
+	}, true
+}
+
+func trimTrailingSpaces(b []byte) string {
+	return strings.TrimRight(string(b), " ")
+}
+
+func formatFATSerial(serial uint32) string {
+	return fmt.Sprintf("%04X-%04X", serial>>16, serial&0xFFFF)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "encoding/binary"
+
+// detectHFS recognizes HFS Plus and HFSX by the "H+"/"HX" signature in the volume header at byte offset
+// 1024 and reads the allocation block size.
+func detectHFS(data []byte) (FSInfo, bool) {
+	const headerOffset = 1024
+	if len(data) < headerOffset+48 {
+		return FSInfo{}, false
+	}
+	sig := string(data[headerOffset : headerOffset+2])
+	if sig != "H+" && sig != "HX" {
+		return FSInfo{}, false
+	}
+	fsType := "HFS+"
+	if sig == "HX" {
+		fsType = "HFSX"
+	}
+	return FSInfo{
+		Type:           fsType,
+		BytesPerSector: uint16(binary.BigEndian.Uint32(data[headerOffset+40 : headerOffset+44])),
+	}, true
+}
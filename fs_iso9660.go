@@ -0,0 +1,27 @@
+package main
+
+import "strings"
+
+// detectISO9660 recognizes an ISO9660 volume by the "CD001" standard identifier in its primary volume
+// descriptor at byte offset 0x8001 and reads the volume identifier.
+func detectISO9660(data []byte) (FSInfo, bool) {
+	const (
+		pvdOffset = 0x8000
+		idOffset  = pvdOffset + 1
+	)
+	if len(data) < idOffset+5 || string(data[idOffset:idOffset+5]) != "CD001" {
+		return FSInfo{}, false
+	}
+
+	label := ""
+	const volumeIDOffset = pvdOffset + 40
+	if len(data) >= volumeIDOffset+32 {
+		label = strings.TrimRight(string(data[volumeIDOffset:volumeIDOffset+32]), " ")
+	}
+
+	return FSInfo{
+		Type:           "ISO9660",
+		Label:          label,
+		BytesPerSector: 2048,
+	}, true
+}
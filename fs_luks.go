@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// detectLUKS recognizes a LUKS-encrypted volume by its "LUKS\xBA\xBE" magic and reads the embedded ASCII
+// UUID (LUKS1 header layout; LUKS2 shares the same magic and UUID offset).
+func detectLUKS(data []byte) (FSInfo, bool) {
+	magic := []byte{'L', 'U', 'K', 'S', 0xBA, 0xBE}
+	if len(data) < 208 || !bytes.Equal(data[0:6], magic) {
+		return FSInfo{}, false
+	}
+	version := binary.BigEndian.Uint16(data[6:8])
+	return FSInfo{
+		Type: fmt.Sprintf("LUKS%d", version),
+		UUID: trimNullString(data[168:208]),
+	}, true
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// detectNTFS recognizes NTFS by its fixed "NTFS    " OEM ID and reads the volume serial number.
+func detectNTFS(data []byte) (FSInfo, bool) {
+	if len(data) < 512 || string(data[3:11]) != "NTFS    " {
+		return FSInfo{}, false
+	}
+	bytesPerSector := binary.LittleEndian.Uint16(data[11:13])
+	serial := binary.LittleEndian.Uint64(data[72:80])
+	return FSInfo{
+		Type:           "NTFS",
+		UUID:           fmt.Sprintf("%016X", serial),
+		BytesPerSector: bytesPerSector,
+	}, true
+}
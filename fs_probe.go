@@ -0,0 +1,64 @@
+package main
+
+import "fmt"
+
+// fsProbeWindow is how many bytes from the start of a partition probeFS is given to work with — enough to
+// reach the furthest signature offset used by any detector (btrfs's label, at 0x10000+0x12B+256).
+const fsProbeWindow = 128 * 1024
+
+// FSInfo describes the filesystem detected by probing the first sectors of a partition.
+type FSInfo struct {
+	Type           string
+	Label          string
+	UUID           string
+	BytesPerSector uint16
+}
+
+// fsDetector inspects a buffer taken from the start of a partition and reports whether it recognized a
+// filesystem there.
+type fsDetector func(data []byte) (FSInfo, bool)
+
+// fsDetectors is the list of filesystem probes probeFS tries in order, one file per filesystem.
+var fsDetectors = []fsDetector{
+	detectFAT,
+	detectExFAT,
+	detectNTFS,
+	detectExt,
+	detectXFS,
+	detectBtrfs,
+	detectHFS,
+	detectAPFS,
+	detectLUKS,
+	detectISO9660,
+	detectSquashfs,
+}
+
+// probeFS inspects the bytes at the start of a partition (data should begin at the partition's StartLBA)
+// and returns the first filesystem signature it recognizes, or FSInfo{Type: "Unknown"} if none match.
+func probeFS(data []byte, startLBA uint64) FSInfo {
+	for _, detect := range fsDetectors {
+		if info, ok := detect(data); ok {
+			return info
+		}
+	}
+	return FSInfo{Type: "Unknown"}
+}
+
+func trimNullString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// formatUUIDBytes renders 16 raw bytes as a canonical big-endian xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+// UUID string, as used by ext/XFS/btrfs filesystem UUIDs.
+func formatUUIDBytes(b []byte) string {
+	if len(b) < 16 {
+		return ""
+	}
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%02x%02x-%02x%02x%02x%02x%02x%02x",
+		b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15])
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestFSDetectors exercises each filesystem probe against a minimal hand-built buffer carrying just
+// enough of that filesystem's on-disk signature to be recognized, guarding against offset mistakes like
+// the HFS+ blockSize/totalBlocks mixup.
+func TestFSDetectors(t *testing.T) {
+	tests := []struct {
+		name       string
+		detect     fsDetector
+		data       []byte
+		wantOK     bool
+		wantType   string
+		wantLabel  string
+		wantUUID   string
+		wantBPS    uint16
+		checkLabel bool
+		checkUUID  bool
+		checkBPS   bool
+	}{
+		{
+			name:       "FAT12/16",
+			detect:     detectFAT,
+			data:       buildFAT16(),
+			wantOK:     true,
+			wantType:   "FAT12/FAT16",
+			wantLabel:  "TESTLABEL",
+			wantUUID:   "1234-5678",
+			wantBPS:    512,
+			checkLabel: true,
+			checkUUID:  true,
+			checkBPS:   true,
+		},
+		{
+			name:       "FAT32",
+			detect:     detectFAT,
+			data:       buildFAT32(),
+			wantOK:     true,
+			wantType:   "FAT32",
+			wantLabel:  "FAT32LABEL",
+			wantUUID:   "ABCD-EF01",
+			wantBPS:    512,
+			checkLabel: true,
+			checkUUID:  true,
+			checkBPS:   true,
+		},
+		{
+			name:      "exFAT",
+			detect:    detectExFAT,
+			data:      buildExFAT(),
+			wantOK:    true,
+			wantType:  "exFAT",
+			wantUUID:  "12345678",
+			wantBPS:   512,
+			checkUUID: true,
+			checkBPS:  true,
+		},
+		{
+			name:      "NTFS",
+			detect:    detectNTFS,
+			data:      buildNTFS(),
+			wantOK:    true,
+			wantType:  "NTFS",
+			wantUUID:  "0123456789ABCDEF",
+			checkUUID: true,
+		},
+		{
+			name:       "ext4",
+			detect:     detectExt,
+			data:       buildExt4(),
+			wantOK:     true,
+			wantType:   "ext4",
+			wantLabel:  "rootfs",
+			checkLabel: true,
+			checkBPS:   true,
+			wantBPS:    512,
+		},
+		{
+			name:       "XFS",
+			detect:     detectXFS,
+			data:       buildXFS(),
+			wantOK:     true,
+			wantType:   "XFS",
+			wantLabel:  "xfslabel",
+			checkLabel: true,
+			checkBPS:   true,
+			wantBPS:    4096,
+		},
+		{
+			name:       "btrfs",
+			detect:     detectBtrfs,
+			data:       buildBtrfs(),
+			wantOK:     true,
+			wantType:   "btrfs",
+			wantLabel:  "btrfslabel",
+			checkLabel: true,
+			checkBPS:   true,
+			wantBPS:    4096,
+		},
+		{
+			name:     "HFS+",
+			detect:   detectHFS,
+			data:     buildHFS("H+", 4096),
+			wantOK:   true,
+			wantType: "HFS+",
+			checkBPS: true,
+			wantBPS:  4096,
+		},
+		{
+			name:     "HFSX",
+			detect:   detectHFS,
+			data:     buildHFS("HX", 512),
+			wantOK:   true,
+			wantType: "HFSX",
+			checkBPS: true,
+			wantBPS:  512,
+		},
+		{
+			name:     "APFS",
+			detect:   detectAPFS,
+			data:     buildAPFS(),
+			wantOK:   true,
+			wantType: "APFS",
+			checkBPS: true,
+			wantBPS:  4096,
+		},
+		{
+			name:      "LUKS1",
+			detect:    detectLUKS,
+			data:      buildLUKS(1),
+			wantOK:    true,
+			wantType:  "LUKS1",
+			wantUUID:  "11111111-2222-3333-4444-555555555555",
+			checkUUID: true,
+		},
+		{
+			name:       "ISO9660",
+			detect:     detectISO9660,
+			data:       buildISO9660(),
+			wantOK:     true,
+			wantType:   "ISO9660",
+			wantLabel:  "MY_ISO",
+			checkLabel: true,
+			checkBPS:   true,
+			wantBPS:    2048,
+		},
+		{
+			name:     "squashfs",
+			detect:   detectSquashfs,
+			data:     buildSquashfs(),
+			wantOK:   true,
+			wantType: "squashfs",
+		},
+		{
+			name:   "unrecognized data",
+			detect: detectFAT,
+			data:   make([]byte, 512),
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info, ok := tc.detect(tc.data)
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !tc.wantOK {
+				return
+			}
+			if info.Type != tc.wantType {
+				t.Errorf("Type = %q, want %q", info.Type, tc.wantType)
+			}
+			if tc.checkLabel && info.Label != tc.wantLabel {
+				t.Errorf("Label = %q, want %q", info.Label, tc.wantLabel)
+			}
+			if tc.checkUUID && info.UUID != tc.wantUUID {
+				t.Errorf("UUID = %q, want %q", info.UUID, tc.wantUUID)
+			}
+			if tc.checkBPS && info.BytesPerSector != tc.wantBPS {
+				t.Errorf("BytesPerSector = %d, want %d", info.BytesPerSector, tc.wantBPS)
+			}
+		})
+	}
+}
+
+func buildFAT16() []byte {
+	b := make([]byte, 512)
+	binary.LittleEndian.PutUint16(b[11:13], 512)        // bytesPerSector
+	binary.LittleEndian.PutUint16(b[22:24], 9)          // fatSize16
+	b[38] = 0x29                                        // extended boot signature
+	binary.LittleEndian.PutUint32(b[39:43], 0x12345678) // volume serial
+	copy(b[43:54], []byte("TESTLABEL  "))
+	b[510], b[511] = 0x55, 0xAA
+	return b
+}
+
+func buildFAT32() []byte {
+	b := make([]byte, 512)
+	binary.LittleEndian.PutUint16(b[11:13], 512) // bytesPerSector
+	// fatSize16 left at 0 to select the FAT32 branch
+	b[66] = 0x29
+	binary.LittleEndian.PutUint32(b[67:71], 0xABCDEF01) // volume serial
+	copy(b[71:82], []byte("FAT32LABEL "))
+	b[510], b[511] = 0x55, 0xAA
+	return b
+}
+
+func buildExFAT() []byte {
+	b := make([]byte, 512)
+	copy(b[3:11], []byte("EXFAT   "))
+	binary.LittleEndian.PutUint32(b[100:104], 0x12345678) // volume serial
+	b[108] = 9                                            // 1<<9 = 512
+	return b
+}
+
+func buildNTFS() []byte {
+	b := make([]byte, 512)
+	copy(b[3:11], []byte("NTFS    "))
+	binary.LittleEndian.PutUint16(b[11:13], 512)
+	binary.LittleEndian.PutUint64(b[72:80], 0x0123456789ABCDEF)
+	return b
+}
+
+func buildExt4() []byte {
+	const superblockOffset = 1024
+	b := make([]byte, superblockOffset+136)
+	sb := b[superblockOffset:]
+	binary.LittleEndian.PutUint16(sb[56:58], 0xEF53)
+	binary.LittleEndian.PutUint32(sb[96:100], 0x0040) // INCOMPAT_EXTENTS -> ext4
+	copy(sb[120:136], []byte("rootfs"))
+	return b
+}
+
+func buildXFS() []byte {
+	b := make([]byte, 120)
+	copy(b[0:4], []byte("XFSB"))
+	binary.BigEndian.PutUint16(b[102:104], 4096)
+	copy(b[108:120], []byte("xfslabel"))
+	return b
+}
+
+func buildBtrfs() []byte {
+	const (
+		superblockOffset = 0x10000
+		magicOffset      = superblockOffset + 0x40
+		fsidOffset       = superblockOffset + 0x20
+		labelOffset      = superblockOffset + 0x12B
+		labelSize        = 256
+	)
+	b := make([]byte, labelOffset+labelSize)
+	copy(b[magicOffset:magicOffset+8], []byte("_BHRfS_M"))
+	copy(b[labelOffset:labelOffset+len("btrfslabel")], []byte("btrfslabel"))
+	return b
+}
+
+func buildHFS(sig string, blockSize uint32) []byte {
+	const headerOffset = 1024
+	b := make([]byte, headerOffset+48)
+	copy(b[headerOffset:headerOffset+2], []byte(sig))
+	binary.BigEndian.PutUint32(b[headerOffset+40:headerOffset+44], blockSize)
+	// totalBlocks deliberately holds a different value so the test fails if the blockSize/totalBlocks
+	// offsets are swapped.
+	binary.BigEndian.PutUint32(b[headerOffset+44:headerOffset+48], 0xFFFFFFFF)
+	return b
+}
+
+func buildAPFS() []byte {
+	b := make([]byte, 36)
+	copy(b[32:36], []byte("NXSB"))
+	return b
+}
+
+func buildLUKS(version uint16) []byte {
+	b := make([]byte, 208)
+	copy(b[0:6], []byte{'L', 'U', 'K', 'S', 0xBA, 0xBE})
+	binary.BigEndian.PutUint16(b[6:8], version)
+	copy(b[168:208], []byte("11111111-2222-3333-4444-555555555555"))
+	return b
+}
+
+func buildISO9660() []byte {
+	const (
+		pvdOffset      = 0x8000
+		idOffset       = pvdOffset + 1
+		volumeIDOffset = pvdOffset + 40
+	)
+	b := make([]byte, volumeIDOffset+32)
+	copy(b[idOffset:idOffset+5], []byte("CD001"))
+	copy(b[volumeIDOffset:volumeIDOffset+6], []byte("MY_ISO"))
+	for i := volumeIDOffset + 6; i < volumeIDOffset+32; i++ {
+		b[i] = ' '
+	}
+	return b
+}
+
+func buildSquashfs() []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b[0:4], 0x73717368)
+	return b
+}
@@ -0,0 +1,12 @@
+package main
+
+import "encoding/binary"
+
+// detectSquashfs recognizes SquashFS by its 0x73717368 ("hsqs") little-endian magic at the start of the
+// superblock.
+func detectSquashfs(data []byte) (FSInfo, bool) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data[0:4]) != 0x73717368 {
+		return FSInfo{}, false
+	}
+	return FSInfo{Type: "squashfs"}, true
+}
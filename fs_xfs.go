@@ -0,0 +1,17 @@
+package main
+
+import "encoding/binary"
+
+// detectXFS recognizes XFS by the "XFSB" magic at the start of its superblock and reads the UUID, sector
+// size, and volume label.
+func detectXFS(data []byte) (FSInfo, bool) {
+	if len(data) < 120 || string(data[0:4]) != "XFSB" {
+		return FSInfo{}, false
+	}
+	return FSInfo{
+		Type:           "XFS",
+		UUID:           formatUUIDBytes(data[32:48]),
+		Label:          trimNullString(data[108:120]),
+		BytesPerSector: binary.BigEndian.Uint16(data[102:104]),
+	}, true
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// parseGPTHeader decodes every field of a 92-byte (or larger) GPT header from raw sector bytes.
+func parseGPTHeader(b []byte) GPTHeader {
+	var h GPTHeader
+	h.Signature = *(*[8]byte)(b[0:8])
+	h.Revision = binary.LittleEndian.Uint32(b[8:12])
+	h.HeaderSize = binary.LittleEndian.Uint32(b[12:16])
+	h.HeaderCRC32 = binary.LittleEndian.Uint32(b[16:20])
+	h.Reserved = binary.LittleEndian.Uint32(b[20:24])
+	h.CurrentLBA = binary.LittleEndian.Uint64(b[24:32])
+	h.BackupLBA = binary.LittleEndian.Uint64(b[32:40])
+	h.FirstUsableLBA = binary.LittleEndian.Uint64(b[40:48])
+	h.LastUsableLBA = binary.LittleEndian.Uint64(b[48:56])
+	h.DiskGUID = *(*[16]byte)(b[56:72])
+	h.PartitionTableLBA = binary.LittleEndian.Uint64(b[72:80])
+	h.NumPartitions = binary.LittleEndian.Uint32(b[80:84])
+	h.PartitionEntrySize = binary.LittleEndian.Uint32(b[84:88])
+	h.PartitionTableCRC = binary.LittleEndian.Uint32(b[88:92])
+	return h
+}
+
+// parseGPTPartitionEntry decodes a single raw 128-byte GPT partition entry.
+func parseGPTPartitionEntry(b []byte) GPTPartition {
+	var p GPTPartition
+	p.TypeGUID = *(*[16]byte)(b[0:16])
+	p.PartitionGUID = *(*[16]byte)(b[16:32])
+	p.StartLBA = binary.LittleEndian.Uint64(b[32:40])
+	p.EndLBA = binary.LittleEndian.Uint64(b[40:48])
+	p.Attributes = binary.LittleEndian.Uint64(b[48:56])
+	copy(p.Name[:], b[56:128])
+	return p
+}
+
+// GPTAttributes decodes the UEFI-defined bits of a GPT partition entry's 64-bit Attributes field: the
+// three standard flag bits, plus the top 16 bits reserved for partition-type-specific use.
+type GPTAttributes struct {
+	RequiredPartition  bool   `json:"requiredPartition"`
+	NoBlockIOProtocol  bool   `json:"noBlockIOProtocol"`
+	LegacyBIOSBootable bool   `json:"legacyBIOSBootable"`
+	TypeSpecific       uint16 `json:"typeSpecific,omitempty"`
+}
+
+// decodeGPTAttributes decodes a GPT partition entry's Attributes field per the UEFI spec.
+func decodeGPTAttributes(attr uint64) GPTAttributes {
+	return GPTAttributes{
+		RequiredPartition:  attr&(1<<0) != 0,
+		NoBlockIOProtocol:  attr&(1<<1) != 0,
+		LegacyBIOSBootable: attr&(1<<2) != 0,
+		TypeSpecific:       uint16(attr >> 48),
+	}
+}
+
+func isZeroGUID(g [16]byte) bool {
+	for _, b := range g {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// gptPartitionName converts a GPT partition's raw UTF-16LE name field into a plain string, falling back to "Unnamed" when empty.
+func gptPartitionName(p GPTPartition) string {
+	name := ""
+	for j := 0; j+1 < len(p.Name); j += 2 {
+		if p.Name[j] == 0 && p.Name[j+1] == 0 {
+			break
+		}
+		if p.Name[j+1] == 0 {
+			name += string(p.Name[j])
+		}
+	}
+	if name == "" {
+		name = "Unnamed"
+	}
+	return name
+}
+
+// gptHeaderCRC32 computes the CRC32 of a GPT header with the HeaderCRC32 field (bytes 16:20) zeroed, matching the self-referential checksum defined by the UEFI spec.
+func gptHeaderCRC32(raw []byte, headerSize uint32) uint32 {
+	if headerSize == 0 || int(headerSize) > len(raw) {
+		headerSize = 92
+	}
+	buf := make([]byte, headerSize)
+	copy(buf, raw[:headerSize])
+	binary.LittleEndian.PutUint32(buf[16:20], 0)
+	return crc32.ChecksumIEEE(buf)
+}
+
+// gptTableCRC32 computes the CRC32 of the raw partition entry array.
+func gptTableCRC32(entries []byte) uint32 {
+	return crc32.ChecksumIEEE(entries)
+}
+
+// ErrInvalidGPT aggregates the structural problems found while validating a GPT header and its partition array: bad signature, bad CRC, out-of-range LBAs, or overlapping partitions.
+type ErrInvalidGPT struct {
+	Issues []string
+}
+
+func (e *ErrInvalidGPT) Error() string {
+	return fmt.Sprintf("invalid GPT: %s", strings.Join(e.Issues, "; "))
+}
+
+// GPTValidation reports which copy of the GPT (primary or backup) was ultimately used, the CRC32s that were checked against it, and any structural issues found.
+type GPTValidation struct {
+	UsedCopy          string   `json:"usedCopy"`
+	HeaderCRCStored   uint32   `json:"headerCRCStored"`
+	HeaderCRCComputed uint32   `json:"headerCRCComputed"`
+	TableCRCStored    uint32   `json:"tableCRCStored"`
+	TableCRCComputed  uint32   `json:"tableCRCComputed"`
+	Issues            []string `json:"issues,omitempty"`
+}
+
+// validateGPTCopy checks one copy (primary or backup) of a GPT header plus its partition entry array: signature, both CRC32s, and per-partition LBA ranges. It returns the decoded partitions, a GPTValidation summary, and an *ErrInvalidGPT if anything didn't check out.
+func validateGPTCopy(label string, headerBytes, entryBytes []byte, header GPTHeader) ([]GPTPartition, *GPTValidation, error) {
+	v := &GPTValidation{UsedCopy: label}
+
+	var issues []string
+	if string(header.Signature[:]) != GPT_SIGNATURE {
+		issues = append(issues, "bad GPT signature")
+	}
+
+	v.HeaderCRCStored = header.HeaderCRC32
+	v.HeaderCRCComputed = gptHeaderCRC32(headerBytes, header.HeaderSize)
+	if v.HeaderCRCStored != v.HeaderCRCComputed {
+		issues = append(issues, fmt.Sprintf("header CRC32 mismatch: stored=%08X computed=%08X", v.HeaderCRCStored, v.HeaderCRCComputed))
+	}
+
+	v.TableCRCStored = header.PartitionTableCRC
+	v.TableCRCComputed = gptTableCRC32(entryBytes)
+	if v.TableCRCStored != v.TableCRCComputed {
+		issues = append(issues, fmt.Sprintf("partition table CRC32 mismatch: stored=%08X computed=%08X", v.TableCRCStored, v.TableCRCComputed))
+	}
+
+	entrySize := int(header.PartitionEntrySize)
+	if entrySize == 0 {
+		entrySize = 128
+	}
+	var partitions []GPTPartition
+	for off := 0; off+entrySize <= len(entryBytes); off += entrySize {
+		p := parseGPTPartitionEntry(entryBytes[off : off+entrySize])
+		if !isZeroGUID(p.TypeGUID) {
+			partitions = append(partitions, p)
+		}
+	}
+
+	issues = append(issues, validatePartitionRanges(partitions, header.FirstUsableLBA, header.LastUsableLBA)...)
+	v.Issues = issues
+
+	if len(issues) > 0 {
+		return partitions, v, &ErrInvalidGPT{Issues: issues}
+	}
+	return partitions, v, nil
+}
+
+// validatePartitionRanges flags any used partition whose LBA range falls outside the header's usable range, or that overlaps another partition's range.
+func validatePartitionRanges(entries []GPTPartition, firstUsable, lastUsable uint64) []string {
+	var issues []string
+	for i, p := range entries {
+		if p.StartLBA < firstUsable || p.EndLBA > lastUsable || p.StartLBA > p.EndLBA {
+			issues = append(issues, fmt.Sprintf("partition %d: LBA range %d-%d outside usable range %d-%d", i+1, p.StartLBA, p.EndLBA, firstUsable, lastUsable))
+		}
+		for j := i + 1; j < len(entries); j++ {
+			q := entries[j]
+			if p.StartLBA <= q.EndLBA && q.StartLBA <= p.EndLBA {
+				issues = append(issues, fmt.Sprintf("partitions %d and %d overlap: %d-%d vs %d-%d", i+1, j+1, p.StartLBA, p.EndLBA, q.StartLBA, q.EndLBA))
+			}
+		}
+	}
+	return issues
+}
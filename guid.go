@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// formatGUID renders a raw GPT GUID (first three fields little-endian, last two big-endian) as the
+// canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx string.
+func formatGUID(g [16]byte) string {
+	return fmt.Sprintf("%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X",
+		binary.LittleEndian.Uint32(g[0:4]),
+		binary.LittleEndian.Uint16(g[4:6]),
+		binary.LittleEndian.Uint16(g[6:8]),
+		g[8], g[9], g[10], g[11], g[12], g[13], g[14], g[15])
+}
+
+// parseGUID parses a canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx GUID string into its raw mixed-endian
+// on-disk representation (first three fields little-endian, last two big-endian).
+func parseGUID(s string) ([16]byte, error) {
+	var g [16]byte
+	hexOnly := strings.ReplaceAll(s, "-", "")
+	raw, err := hex.DecodeString(hexOnly)
+	if err != nil || len(raw) != 16 {
+		return g, fmt.Errorf("invalid GUID %q", s)
+	}
+
+	binary.LittleEndian.PutUint32(g[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(g[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(g[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(g[8:16], raw[8:16])
+	return g, nil
+}
+
+// wellKnownTypeGUIDs maps canonical GPT partition type GUID strings to human-readable descriptions.
+var wellKnownTypeGUIDs = map[string]string{
+	"00000000-0000-0000-0000-000000000000": "Unused entry",
+	"024DEE41-33E7-11D3-9D69-0008C781F39F": "MBR partition scheme",
+	"C12A7328-F81F-11D2-BA4B-00A0C93EC93B": "EFI System Partition",
+	"EBD0A0A2-B9E5-4433-87C0-68B6B72699C7": "Microsoft Basic Data",
+	"E3C9E316-0B5C-4DB8-817D-F92DF00215AE": "Microsoft Reserved",
+	"5808C8AA-7E8F-42E0-85D2-E1E90434CFB3": "Windows LDM Metadata",
+	"AF9B60A0-1431-4F62-BC68-3311714A69AD": "Windows LDM Data",
+	"DE94BBA4-06D1-4D40-A16A-BFD50179D6AC": "Windows Recovery Environment",
+	"0FC63DAF-8483-4772-8E79-3D69D8477DE4": "Linux filesystem",
+	"0657FD6D-A4AB-43C4-84E5-0933C84B4F4F": "Linux swap",
+	"E6D6D379-F507-44C2-A23C-238F2A3DF928": "Linux LVM",
+	"A19D880F-05FC-4D3B-A006-743F0F84911E": "Linux RAID",
+	"CA7D7CCB-63ED-4C53-861C-1742536059CC": "Linux LUKS",
+	"83BD6B9D-7F41-11DC-BE0B-001560B84F0F": "FreeBSD boot",
+	"516E7CB4-6ECF-11D6-8FF8-00022D09712B": "FreeBSD disklabel",
+	"516E7CB5-6ECF-11D6-8FF8-00022D09712B": "FreeBSD swap",
+	"516E7CB6-6ECF-11D6-8FF8-00022D09712B": "FreeBSD UFS",
+	"516E7CB8-6ECF-11D6-8FF8-00022D09712B": "FreeBSD Vinum/RAID",
+	"516E7CBA-6ECF-11D6-8FF8-00022D09712B": "FreeBSD ZFS",
+	"48465300-0000-11AA-AA11-00306543ECAC": "Apple HFS+",
+	"7C3457EF-0000-11AA-AA11-00306543ECAC": "Apple APFS",
+	"55465300-0000-11AA-AA11-00306543ECAC": "Apple UFS",
+	"52414944-0000-11AA-AA11-00306543ECAC": "Apple RAID",
+	"426F6F74-0000-11AA-AA11-00306543ECAC": "Apple Boot",
+	"4C616265-6C00-11AA-AA11-00306543ECAC": "Apple Label",
+	"FE3A2A5D-4F32-41A7-B725-ACCC3285A309": "ChromeOS kernel",
+	"3CB8E202-3B7E-47DD-8A3C-7FF2A13CFCEC": "ChromeOS rootfs",
+	"2E0A753D-9E48-43B0-8337-B15192CB1B5E": "ChromeOS reserved",
+	"CAB6E88E-ABF3-4102-A07A-D4BB9BE3C1D3": "ChromeOS firmware",
+}
+
+// gptTypeGUIDDescription looks up a GPT partition type GUID in the well-known type table, returning
+// "Unknown" for anything not recognized.
+func gptTypeGUIDDescription(g [16]byte) string {
+	if desc, ok := wellKnownTypeGUIDs[formatGUID(g)]; ok {
+		return desc
+	}
+	return "Unknown"
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// TestParseFormatGUIDRoundTrip checks that parseGUID and formatGUID are inverses for well-known type
+// GUIDs, which exercises the mixed-endian (first three fields little-endian, last two big-endian)
+// encoding in both directions.
+func TestParseFormatGUIDRoundTrip(t *testing.T) {
+	for canonical := range wellKnownTypeGUIDs {
+		t.Run(canonical, func(t *testing.T) {
+			raw, err := parseGUID(canonical)
+			if err != nil {
+				t.Fatalf("parseGUID(%q): %v", canonical, err)
+			}
+			if got := formatGUID(raw); got != canonical {
+				t.Errorf("formatGUID(parseGUID(%q)) = %q, want %q", canonical, got, canonical)
+			}
+		})
+	}
+}
+
+// TestFormatGUIDMixedEndian pins down the mixed-endian byte layout against a known raw encoding, so a
+// regression that swaps the little-endian/big-endian halves is caught even if parseGUID has the same bug.
+func TestFormatGUIDMixedEndian(t *testing.T) {
+	raw := [16]byte{
+		0x28, 0x73, 0x2A, 0xC1, // TimeLow, little-endian
+		0x1F, 0xF8, // TimeMid, little-endian
+		0xD2, 0x11, // TimeHiAndVersion, little-endian
+		0xBA, 0x4B, // ClockSeq, big-endian
+		0x00, 0xA0, 0xC9, 0x3E, 0xC9, 0x3B, // Node, big-endian
+	}
+	const want = "C12A7328-F81F-11D2-BA4B-00A0C93EC93B"
+	if got := formatGUID(raw); got != want {
+		t.Fatalf("formatGUID(%v) = %q, want %q", raw, got, want)
+	}
+}
+
+func TestParseGUIDInvalid(t *testing.T) {
+	for _, s := range []string{"", "not-a-guid", "C12A7328-F81F-11D2-BA4B-00A0C93EC93"} {
+		if _, err := parseGUID(s); err == nil {
+			t.Errorf("parseGUID(%q): expected error, got nil", s)
+		}
+	}
+}
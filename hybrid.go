@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// HybridMismatch describes a discrepancy between a hybrid MBR partition entry and the GPT partition it is
+// meant to mirror, mirroring the FindHybridMismatches/FindOverlaps checks full GPT libraries perform.
+type HybridMismatch struct {
+	MBREntry    int    `json:"mbrEntry"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Remediation string `json:"remediation"`
+}
+
+// HybridMBRReport summarizes whether a disk uses a hybrid MBR layout and any cross-consistency issues
+// found between its MBR entries and the GPT partition array.
+type HybridMBRReport struct {
+	IsHybrid   bool             `json:"isHybrid"`
+	Mismatches []HybridMismatch `json:"mismatches,omitempty"`
+}
+
+// parseMBREntries decodes the four primary MBR partition entries from a raw MBR sector.
+func parseMBREntries(mbr []byte) [4]MBRPartition {
+	var entries [4]MBRPartition
+	for i := 0; i < 4; i++ {
+		off := 446 + i*16
+		entries[i] = MBRPartition{
+			Status:     mbr[off],
+			StartCHS:   [3]uint8{mbr[off+1], mbr[off+2], mbr[off+3]},
+			Type:       mbr[off+4],
+			EndCHS:     [3]uint8{mbr[off+5], mbr[off+6], mbr[off+7]},
+			StartLBA:   binary.LittleEndian.Uint32(mbr[off+8 : off+12]),
+			SizeBlocks: binary.LittleEndian.Uint32(mbr[off+12 : off+16]),
+		}
+	}
+	return entries
+}
+
+// isHybridMBR reports whether entries contains both a protective 0xEE entry and at least one other
+// non-empty entry — the telltale layout of a hybrid MBR, most often seen on Mac dual-boot disks.
+func isHybridMBR(entries [4]MBRPartition) bool {
+	hasProtective := false
+	hasOther := false
+	for _, e := range entries {
+		switch {
+		case e.Type == 0xEE:
+			hasProtective = true
+		case e.Type != 0x00:
+			hasOther = true
+		}
+	}
+	return hasProtective && hasOther
+}
+
+// crossCheckHybridMBR compares each non-empty, non-protective MBR entry against the GPT partition array,
+// flagging entries with no corresponding GPT partition, a differing LBA range, or a range that overlaps
+// more than one GPT partition.
+func crossCheckHybridMBR(entries [4]MBRPartition, gptPartitions []GPTPartition) []HybridMismatch {
+	var mismatches []HybridMismatch
+	for i, e := range entries {
+		if e.Type == 0x00 || e.Type == 0xEE {
+			continue
+		}
+		mbrStart := uint64(e.StartLBA)
+		mbrEnd := uint64(e.StartLBA) + uint64(e.SizeBlocks) - 1
+
+		var overlapping []GPTPartition
+		for _, p := range gptPartitions {
+			if mbrStart <= p.EndLBA && p.StartLBA <= mbrEnd {
+				overlapping = append(overlapping, p)
+			}
+		}
+
+		switch {
+		case len(overlapping) == 0:
+			mismatches = append(mismatches, HybridMismatch{
+				MBREntry:    i + 1,
+				Description: fmt.Sprintf("MBR entry %d (type 0x%02X, LBA %d-%d) has no corresponding GPT partition", i+1, e.Type, mbrStart, mbrEnd),
+				Severity:    "error",
+				Remediation: "Remove the orphaned hybrid MBR entry or add a matching GPT partition",
+			})
+		case len(overlapping) > 1:
+			mismatches = append(mismatches, HybridMismatch{
+				MBREntry:    i + 1,
+				Description: fmt.Sprintf("MBR entry %d (LBA %d-%d) overlaps %d GPT partitions", i+1, mbrStart, mbrEnd, len(overlapping)),
+				Severity:    "error",
+				Remediation: "Recreate the hybrid MBR so each entry maps to exactly one GPT partition",
+			})
+		case overlapping[0].StartLBA != mbrStart || overlapping[0].EndLBA != mbrEnd:
+			mismatches = append(mismatches, HybridMismatch{
+				MBREntry:    i + 1,
+				Description: fmt.Sprintf("MBR entry %d (LBA %d-%d) differs from its GPT partition (LBA %d-%d)", i+1, mbrStart, mbrEnd, overlapping[0].StartLBA, overlapping[0].EndLBA),
+				Severity:    "warning",
+				Remediation: "Rebuild the hybrid MBR entry's start LBA and size to match the GPT partition",
+			})
+		}
+	}
+	return mismatches
+}
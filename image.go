@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// ImageReader abstracts random access to a disk image, whether it's a raw file, a sparse virtual disk
+// container (VMDK/VHD/VHDX), or a compressed disc image (WBFS/CISO/RVZ/WIA). MBR/GPT parsing works
+// unchanged over any ImageReader, since it only ever needs ReadAt/Size.
+type ImageReader interface {
+	ReadAt(p []byte, off int64) (int, error)
+	Size() int64
+}
+
+// rawImageReader passes reads straight through to the underlying reader; it backs plain disk images that
+// don't need any container-format translation.
+type rawImageReader struct {
+	r    io.ReaderAt
+	size int64
+}
+
+func (r *rawImageReader) ReadAt(p []byte, off int64) (int, error) { return r.r.ReadAt(p, off) }
+func (r *rawImageReader) Size() int64                             { return r.size }
+
+// OpenImage detects the container format of an image by its magic bytes and returns an ImageReader that
+// presents it as a flat address space, falling back to a raw passthrough reader when nothing matches.
+func OpenImage(r io.ReaderAt, size int64) (ImageReader, error) {
+	magic := make([]byte, 16)
+	n, err := r.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("reading image magic: %w", err)
+	}
+	magic = magic[:n]
+
+	switch {
+	case hasMagic(magic, "WBFS"):
+		return newWBFSReader(r, size)
+	case hasMagic(magic, "CISO"):
+		return newCISOReader(r, size)
+	case hasMagic(magic, "RVZ\x01"), hasMagic(magic, "WIA\x01"):
+		return newRVZWIAReader(r, size)
+	case hasMagic(magic, "KDMV"):
+		return newVMDKReader(r, size)
+	case hasMagic(magic, "conectix"):
+		return newVHDReader(r, size, 0)
+	case hasMagic(magic, "vhdxfile"):
+		return newVHDXReader(r, size)
+	}
+
+	// Fixed VHDs only carry their "conectix" footer at the end of the file, not the start.
+	if size >= 512 {
+		footer := make([]byte, 8)
+		if _, err := r.ReadAt(footer, size-512); err == nil && string(footer) == "conectix" {
+			return newVHDReader(r, size, size-512)
+		}
+	}
+
+	return &rawImageReader{r: r, size: size}, nil
+}
+
+func hasMagic(b []byte, magic string) bool {
+	return len(b) >= len(magic) && string(b[:len(magic)]) == magic
+}
+
+func copyZero(dst []byte, n int) int {
+	if n > len(dst) {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = 0
+	}
+	return n
+}
+
+// groupCache is a small fixed-capacity LRU cache of decompressed payloads keyed by an integer index, used
+// by the RVZ/WIA reader to avoid repeatedly decompressing the same group.
+type groupCache struct {
+	capacity int
+	order    []int
+	data     map[int][]byte
+}
+
+func newGroupCache(capacity int) *groupCache {
+	return &groupCache{capacity: capacity, data: make(map[int][]byte)}
+}
+
+func (c *groupCache) get(index int) ([]byte, bool) {
+	b, ok := c.data[index]
+	if ok {
+		c.touch(index)
+	}
+	return b, ok
+}
+
+func (c *groupCache) put(index int, data []byte) {
+	if _, exists := c.data[index]; !exists && len(c.data) >= c.capacity && len(c.order) > 0 {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		delete(c.data, oldest)
+	}
+	c.data[index] = data
+	c.touch(index)
+}
+
+func (c *groupCache) touch(index int) {
+	for i, v := range c.order {
+		if v == index {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, index)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	cisoHeaderSize = 0x8008
+	cisoMapSize    = 0x8000
+)
+
+// cisoReader presents a GameCube/Wii "CISO" sparse image (as produced by wit/dolphin) as a flat image.
+// Only blocks flagged present in the header's bitmap are actually stored, packed sequentially after the
+// header; absent blocks read back as zeros.
+type cisoReader struct {
+	r           io.ReaderAt
+	blockSize   int64
+	present     [cisoMapSize]bool
+	blockOffset [cisoMapSize]int64 // file offset of each present block, -1 if absent
+}
+
+func newCISOReader(r io.ReaderAt, size int64) (ImageReader, error) {
+	header := make([]byte, cisoHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading CISO header: %w", err)
+	}
+	if string(header[0:4]) != "CISO" {
+		return nil, fmt.Errorf("not a CISO image")
+	}
+
+	blockSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid CISO block size")
+	}
+
+	cr := &cisoReader{r: r, blockSize: blockSize}
+
+	offset := int64(cisoHeaderSize)
+	for i := 0; i < cisoMapSize; i++ {
+		if header[8+i] != 0 {
+			cr.present[i] = true
+			cr.blockOffset[i] = offset
+			offset += blockSize
+		} else {
+			cr.blockOffset[i] = -1
+		}
+	}
+
+	return cr, nil
+}
+
+func (c *cisoReader) Size() int64 { return int64(cisoMapSize) * c.blockSize }
+
+func (c *cisoReader) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		pos := off + int64(total)
+		block := int(pos / c.blockSize)
+		blockOff := pos % c.blockSize
+		if block >= cisoMapSize {
+			if total == 0 {
+				return 0, io.EOF
+			}
+			return total, nil
+		}
+
+		n := len(p) - total
+		if int64(n) > c.blockSize-blockOff {
+			n = int(c.blockSize - blockOff)
+		}
+
+		if !c.present[block] {
+			total += copyZero(p[total:total+n], n)
+			continue
+		}
+
+		chunk := make([]byte, c.blockSize)
+		if _, err := c.r.ReadAt(chunk, c.blockOffset[block]); err != nil {
+			return total, err
+		}
+		total += copy(p[total:total+n], chunk[blockOff:blockOff+int64(n)])
+	}
+	return total, nil
+}
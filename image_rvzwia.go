@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+const (
+	wiaCompressionNone  = 0
+	wiaCompressionPurge = 1
+	wiaCompressionBzip2 = 2
+	wiaCompressionLZMA  = 3
+	wiaCompressionLZMA2 = 4
+	wiaCompressionZstd  = 5
+)
+
+// wiaGroupEntry is a single entry in a WIA/RVZ group table: the compressed payload's location (in units of
+// 4 bytes) and size. The top bit of the size flags an RVZ group stored uncompressed.
+type wiaGroupEntry struct {
+	dataOffset int64
+	dataSize   uint32
+}
+
+// rvzWIAReader implements Dolphin's WIA and RVZ disc container formats: it parses the header to locate the
+// group table, then decompresses each requested group on demand into a small LRU cache keyed by group
+// index. Partition-aware (encrypted/hashed) decoding is not performed; groups are returned as stored.
+type rvzWIAReader struct {
+	r               io.ReaderAt
+	size            int64
+	chunkSize       int64
+	compressionType uint32
+	groups          []wiaGroupEntry
+	cache           *groupCache
+}
+
+func newRVZWIAReader(r io.ReaderAt, size int64) (ImageReader, error) {
+	header1 := make([]byte, 0x24)
+	if _, err := r.ReadAt(header1, 0); err != nil {
+		return nil, fmt.Errorf("reading WIA/RVZ header: %w", err)
+	}
+	magic := string(header1[0:3])
+	if magic != "WIA" && magic != "RVZ" {
+		return nil, fmt.Errorf("not a WIA/RVZ image")
+	}
+	header2Size := binary.BigEndian.Uint32(header1[12:16])
+	if header2Size < 0xD4 {
+		return nil, fmt.Errorf("WIA/RVZ header2 too small")
+	}
+
+	header2 := make([]byte, header2Size)
+	if _, err := r.ReadAt(header2, 0x24); err != nil {
+		return nil, fmt.Errorf("reading WIA/RVZ disc header: %w", err)
+	}
+
+	compressionType := binary.BigEndian.Uint32(header2[4:8])
+	chunkSize := int64(binary.BigEndian.Uint32(header2[12:16]))
+	numGroupEntries := binary.BigEndian.Uint32(header2[0xC4:0xC8])
+	groupEntriesOffset := int64(binary.BigEndian.Uint64(header2[0xC8:0xD0]))
+
+	groupBytes := make([]byte, int64(numGroupEntries)*8)
+	if _, err := r.ReadAt(groupBytes, groupEntriesOffset); err != nil {
+		return nil, fmt.Errorf("reading WIA/RVZ group table: %w", err)
+	}
+
+	groups := make([]wiaGroupEntry, numGroupEntries)
+	for i := range groups {
+		e := groupBytes[i*8 : i*8+8]
+		groups[i] = wiaGroupEntry{
+			dataOffset: int64(binary.BigEndian.Uint32(e[0:4])) * 4,
+			dataSize:   binary.BigEndian.Uint32(e[4:8]),
+		}
+	}
+
+	return &rvzWIAReader{
+		r:               r,
+		size:            int64(numGroupEntries) * chunkSize,
+		chunkSize:       chunkSize,
+		compressionType: compressionType,
+		groups:          groups,
+		cache:           newGroupCache(16),
+	}, nil
+}
+
+func (w *rvzWIAReader) Size() int64 { return w.size }
+
+// decompressGroup decompresses the group at the given index according to the image's compression type,
+// caching the result so repeated reads of the same group are cheap.
+func (w *rvzWIAReader) decompressGroup(index int) ([]byte, error) {
+	if cached, ok := w.cache.get(index); ok {
+		return cached, nil
+	}
+	if index < 0 || index >= len(w.groups) {
+		return nil, fmt.Errorf("group index %d out of range", index)
+	}
+	g := w.groups[index]
+	if g.dataSize == 0 {
+		zero := make([]byte, w.chunkSize)
+		w.cache.put(index, zero)
+		return zero, nil
+	}
+
+	rawSize := g.dataSize &^ (1 << 31)
+	storedUncompressed := g.dataSize&(1<<31) != 0
+
+	compressed := make([]byte, rawSize)
+	if _, err := w.r.ReadAt(compressed, g.dataOffset); err != nil {
+		return nil, fmt.Errorf("reading group %d: %w", index, err)
+	}
+
+	if storedUncompressed || w.compressionType == wiaCompressionNone || w.compressionType == wiaCompressionPurge {
+		w.cache.put(index, compressed)
+		return compressed, nil
+	}
+
+	var out []byte
+	var err error
+	switch w.compressionType {
+	case wiaCompressionBzip2:
+		out, err = io.ReadAll(bzip2.NewReader(bytes.NewReader(compressed)))
+	case wiaCompressionLZMA, wiaCompressionLZMA2:
+		var lr *lzma.Reader
+		lr, err = lzma.NewReader(bytes.NewReader(compressed))
+		if err == nil {
+			out, err = io.ReadAll(lr)
+		}
+	case wiaCompressionZstd:
+		var dec *zstd.Decoder
+		dec, err = zstd.NewReader(bytes.NewReader(compressed))
+		if err == nil {
+			out, err = io.ReadAll(dec)
+			dec.Close()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WIA/RVZ compression type %d", w.compressionType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompressing group %d: %w", index, err)
+	}
+
+	w.cache.put(index, out)
+	return out, nil
+}
+
+func (w *rvzWIAReader) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		pos := off + int64(total)
+		groupIndex := int(pos / w.chunkSize)
+		within := pos % w.chunkSize
+
+		group, err := w.decompressGroup(groupIndex)
+		if err != nil {
+			return total, err
+		}
+
+		n := len(p) - total
+		if int64(n) > int64(len(group))-within {
+			n = int(int64(len(group)) - within)
+		}
+		if n <= 0 {
+			if total == 0 {
+				return 0, io.EOF
+			}
+			return total, nil
+		}
+		copy(p[total:total+n], group[within:within+int64(n)])
+		total += n
+	}
+	return total, nil
+}
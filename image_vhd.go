@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	vhdFooterSize  = 512
+	vhdTypeFixed   = 2
+	vhdTypeDynamic = 3
+)
+
+// vhdReader implements Microsoft's classic VHD format: fixed disks (a flat image plus a trailing footer)
+// and dynamic disks (sparse blocks addressed through a Block Allocation Table). Differencing disks (parent
+// chains) are not supported.
+type vhdReader struct {
+	r          io.ReaderAt
+	diskType   uint32
+	size       int64
+	blockSize  int64
+	bat        []uint32
+	bitmapSize int64
+}
+
+// newVHDReader reads the 512-byte footer at footerOffset (0 for dynamic/differencing disks, which keep a
+// copy at the start of the file; size-512 for fixed disks, which only have the trailing copy).
+func newVHDReader(r io.ReaderAt, fileSize int64, footerOffset int64) (ImageReader, error) {
+	footer := make([]byte, vhdFooterSize)
+	if _, err := r.ReadAt(footer, footerOffset); err != nil {
+		return nil, fmt.Errorf("reading VHD footer: %w", err)
+	}
+	if string(footer[0:8]) != "conectix" {
+		return nil, fmt.Errorf("not a VHD image")
+	}
+
+	diskType := binary.BigEndian.Uint32(footer[60:64])
+	currentSize := int64(binary.BigEndian.Uint64(footer[48:56]))
+
+	v := &vhdReader{r: r, diskType: diskType, size: currentSize}
+
+	if diskType == vhdTypeFixed {
+		return v, nil
+	}
+	if diskType != vhdTypeDynamic {
+		return nil, fmt.Errorf("unsupported VHD disk type %d (differencing disks are not supported)", diskType)
+	}
+
+	dataOffset := int64(binary.BigEndian.Uint64(footer[16:24]))
+	header := make([]byte, 1024)
+	if _, err := r.ReadAt(header, dataOffset); err != nil {
+		return nil, fmt.Errorf("reading VHD dynamic disk header: %w", err)
+	}
+	if string(header[0:8]) != "cxsparse" {
+		return nil, fmt.Errorf("invalid VHD dynamic disk header")
+	}
+
+	tableOffset := int64(binary.BigEndian.Uint64(header[16:24]))
+	maxEntries := binary.BigEndian.Uint32(header[28:32])
+	blockSize := int64(binary.BigEndian.Uint32(header[32:36]))
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid VHD block size")
+	}
+
+	batBytes := make([]byte, int64(maxEntries)*4)
+	if _, err := r.ReadAt(batBytes, tableOffset); err != nil {
+		return nil, fmt.Errorf("reading VHD block allocation table: %w", err)
+	}
+	bat := make([]uint32, maxEntries)
+	for i := range bat {
+		bat[i] = binary.BigEndian.Uint32(batBytes[i*4 : i*4+4])
+	}
+
+	// Each data block is preceded by a sector bitmap, rounded up to a 512-byte sector.
+	bitmapSize := ((blockSize/512 + 7) / 8 + 511) / 512 * 512
+
+	v.blockSize = blockSize
+	v.bat = bat
+	v.bitmapSize = bitmapSize
+	return v, nil
+}
+
+func (v *vhdReader) Size() int64 { return v.size }
+
+func (v *vhdReader) ReadAt(p []byte, off int64) (int, error) {
+	if v.diskType == vhdTypeFixed {
+		return v.r.ReadAt(p, off)
+	}
+
+	total := 0
+	for total < len(p) {
+		pos := off + int64(total)
+		blockIndex := pos / v.blockSize
+		within := pos % v.blockSize
+
+		n := len(p) - total
+		if int64(n) > v.blockSize-within {
+			n = int(v.blockSize - within)
+		}
+
+		if int(blockIndex) >= len(v.bat) || v.bat[blockIndex] == 0xFFFFFFFF {
+			total += copyZero(p[total:total+n], n)
+			continue
+		}
+
+		blockOffset := int64(v.bat[blockIndex])*512 + v.bitmapSize + within
+		read, err := v.r.ReadAt(p[total:total+n], blockOffset)
+		if err != nil {
+			return total, err
+		}
+		total += read
+	}
+	return total, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// VHDX region/metadata item GUIDs, stored on disk as standard Windows GUIDs (first three fields
+// little-endian, last field big-endian).
+var (
+	vhdxBATRegionGUID             = [16]byte{0x66, 0x77, 0xC2, 0x2D, 0x23, 0xF6, 0x00, 0x42, 0x9D, 0x64, 0x11, 0x5E, 0x9B, 0xFD, 0x4A, 0x08}
+	vhdxMetadataRegionGUID        = [16]byte{0x06, 0xA2, 0x7C, 0x8B, 0x90, 0x47, 0x9A, 0x4B, 0xB8, 0xFE, 0x57, 0x5F, 0x05, 0x0F, 0x88, 0x6E}
+	vhdxFileParametersItemGUID    = [16]byte{0x37, 0x67, 0xA1, 0xCA, 0x36, 0xFA, 0x43, 0x4D, 0xB3, 0xB6, 0x33, 0xF0, 0xAA, 0x44, 0xE7, 0x6B}
+	vhdxVirtualDiskSizeItemGUID   = [16]byte{0x24, 0x42, 0xA5, 0x2F, 0x1B, 0xCD, 0x76, 0x48, 0xB2, 0x11, 0x5D, 0xBE, 0xD8, 0x3B, 0xF4, 0xB8}
+	vhdxLogicalSectorSizeItemGUID = [16]byte{0x1D, 0xBF, 0x41, 0x81, 0x6F, 0xA9, 0x09, 0x47, 0xBA, 0x47, 0xF2, 0x33, 0xA8, 0xFA, 0xAB, 0x5F}
+)
+
+const vhdxBatPayloadFullyPresent = 6
+
+// vhdxReader implements enough of Microsoft's VHDX format (MS-VHDX) to read a standalone, cleanly shut
+// down image: the file header identifier, region table, BAT, and the File Parameters/Virtual Disk
+// Size/Logical Sector Size metadata items. Differencing disks and journal (log) replay are not supported;
+// VHDX images must have been shut down cleanly.
+type vhdxReader struct {
+	r          io.ReaderAt
+	size       int64
+	blockSize  int64
+	bat        []uint64
+	chunkRatio int64
+}
+
+func newVHDXReader(r io.ReaderAt, size int64) (ImageReader, error) {
+	ident := make([]byte, 8)
+	if _, err := r.ReadAt(ident, 0); err != nil {
+		return nil, fmt.Errorf("reading VHDX identifier: %w", err)
+	}
+	if string(ident) != "vhdxfile" {
+		return nil, fmt.Errorf("not a VHDX image")
+	}
+
+	const regionTableOffset = 192 * 1024
+	regionHeader := make([]byte, 16)
+	if _, err := r.ReadAt(regionHeader, regionTableOffset); err != nil {
+		return nil, fmt.Errorf("reading VHDX region table: %w", err)
+	}
+	if string(regionHeader[0:4]) != "regi" {
+		return nil, fmt.Errorf("invalid VHDX region table")
+	}
+	entryCount := binary.LittleEndian.Uint32(regionHeader[8:12])
+
+	entries := make([]byte, int64(entryCount)*32)
+	if _, err := r.ReadAt(entries, regionTableOffset+16); err != nil {
+		return nil, fmt.Errorf("reading VHDX region entries: %w", err)
+	}
+
+	var batOffset, metadataOffset int64
+	for i := uint32(0); i < entryCount; i++ {
+		e := entries[i*32 : i*32+32]
+		var guid [16]byte
+		copy(guid[:], e[0:16])
+		fileOffset := int64(binary.LittleEndian.Uint64(e[16:24]))
+		switch guid {
+		case vhdxBATRegionGUID:
+			batOffset = fileOffset
+		case vhdxMetadataRegionGUID:
+			metadataOffset = fileOffset
+		}
+	}
+	if batOffset == 0 || metadataOffset == 0 {
+		return nil, fmt.Errorf("VHDX image is missing its BAT or metadata region")
+	}
+
+	metaHeader := make([]byte, 12)
+	if _, err := r.ReadAt(metaHeader, metadataOffset); err != nil {
+		return nil, fmt.Errorf("reading VHDX metadata table: %w", err)
+	}
+	if string(metaHeader[0:8]) != "metadata" {
+		return nil, fmt.Errorf("invalid VHDX metadata table")
+	}
+	metaEntryCount := binary.LittleEndian.Uint16(metaHeader[10:12])
+
+	metaEntries := make([]byte, int64(metaEntryCount)*32)
+	if _, err := r.ReadAt(metaEntries, metadataOffset+32); err != nil {
+		return nil, fmt.Errorf("reading VHDX metadata entries: %w", err)
+	}
+
+	var blockSize, virtualDiskSize, logicalSectorSize int64
+	for i := uint16(0); i < metaEntryCount; i++ {
+		e := metaEntries[i*32 : i*32+32]
+		var guid [16]byte
+		copy(guid[:], e[0:16])
+		itemOffset := int64(binary.LittleEndian.Uint32(e[16:20]))
+
+		switch guid {
+		case vhdxFileParametersItemGUID:
+			buf := make([]byte, 4)
+			if _, err := r.ReadAt(buf, metadataOffset+itemOffset); err == nil {
+				blockSize = int64(binary.LittleEndian.Uint32(buf))
+			}
+		case vhdxVirtualDiskSizeItemGUID:
+			buf := make([]byte, 8)
+			if _, err := r.ReadAt(buf, metadataOffset+itemOffset); err == nil {
+				virtualDiskSize = int64(binary.LittleEndian.Uint64(buf))
+			}
+		case vhdxLogicalSectorSizeItemGUID:
+			buf := make([]byte, 4)
+			if _, err := r.ReadAt(buf, metadataOffset+itemOffset); err == nil {
+				logicalSectorSize = int64(binary.LittleEndian.Uint32(buf))
+			}
+		}
+	}
+	if blockSize <= 0 || virtualDiskSize <= 0 || logicalSectorSize <= 0 {
+		return nil, fmt.Errorf("VHDX image is missing required metadata items")
+	}
+
+	// The BAT interleaves one sector-bitmap entry after every chunkRatio data-block entries.
+	chunkRatio := (int64(1) << 23) * logicalSectorSize / blockSize
+	numDataBlocks := (virtualDiskSize + blockSize - 1) / blockSize
+	numBatEntries := numDataBlocks + (numDataBlocks-1)/chunkRatio + 1
+
+	batBytes := make([]byte, numBatEntries*8)
+	if _, err := r.ReadAt(batBytes, batOffset); err != nil {
+		return nil, fmt.Errorf("reading VHDX block allocation table: %w", err)
+	}
+	bat := make([]uint64, numBatEntries)
+	for i := range bat {
+		bat[i] = binary.LittleEndian.Uint64(batBytes[i*8 : i*8+8])
+	}
+
+	return &vhdxReader{
+		r:          r,
+		size:       virtualDiskSize,
+		blockSize:  blockSize,
+		bat:        bat,
+		chunkRatio: chunkRatio,
+	}, nil
+}
+
+func (v *vhdxReader) Size() int64 { return v.size }
+
+func (v *vhdxReader) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		pos := off + int64(total)
+		blockIndex := pos / v.blockSize
+		within := pos % v.blockSize
+
+		n := len(p) - total
+		if int64(n) > v.blockSize-within {
+			n = int(v.blockSize - within)
+		}
+
+		batIndex := blockIndex + blockIndex/v.chunkRatio
+		if int(batIndex) >= len(v.bat) {
+			if total == 0 {
+				return 0, io.EOF
+			}
+			return total, nil
+		}
+
+		entry := v.bat[batIndex]
+		state := entry & 0x7
+		fileOffsetMB := entry >> 20
+
+		if state != vhdxBatPayloadFullyPresent {
+			total += copyZero(p[total:total+n], n)
+			continue
+		}
+
+		read, err := v.r.ReadAt(p[total:total+n], int64(fileOffsetMB)*1024*1024+within)
+		if err != nil {
+			return total, err
+		}
+		total += read
+	}
+	return total, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const vmdkSectorSize = 512
+
+// vmdkReader implements VMware's classic "monolithic sparse" extent format (a single KDMV-signed file with
+// its own grain directory), which is what VMDKs produced by most tools outside ESXi use. Split extents and
+// streamOptimized (compressed) extents are not supported.
+type vmdkReader struct {
+	r          io.ReaderAt
+	capacity   int64 // sectors
+	grainSize  int64 // sectors
+	grainDir   []uint32
+	gtesPerGT  int64
+	grainTable map[uint32][]uint32 // grain dir index -> grain table entries, loaded on demand
+}
+
+func newVMDKReader(r io.ReaderAt, size int64) (ImageReader, error) {
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading VMDK header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(header[0:4]) != 0x564d444b {
+		return nil, fmt.Errorf("not a VMDK sparse image")
+	}
+
+	capacity := int64(binary.LittleEndian.Uint64(header[12:20]))
+	grainSize := int64(binary.LittleEndian.Uint64(header[20:28]))
+	numGTEsPerGT := int64(binary.LittleEndian.Uint32(header[44:48]))
+	gdOffset := int64(binary.LittleEndian.Uint64(header[56:64]))
+	if grainSize <= 0 || numGTEsPerGT <= 0 {
+		return nil, fmt.Errorf("invalid VMDK grain geometry")
+	}
+
+	grainTableSpan := grainSize * numGTEsPerGT
+	numGTs := (capacity + grainTableSpan - 1) / grainTableSpan
+	gdBytes := make([]byte, numGTs*4)
+	if _, err := r.ReadAt(gdBytes, gdOffset*vmdkSectorSize); err != nil {
+		return nil, fmt.Errorf("reading VMDK grain directory: %w", err)
+	}
+	grainDir := make([]uint32, numGTs)
+	for i := range grainDir {
+		grainDir[i] = binary.LittleEndian.Uint32(gdBytes[i*4 : i*4+4])
+	}
+
+	return &vmdkReader{
+		r:          r,
+		capacity:   capacity,
+		grainSize:  grainSize,
+		grainDir:   grainDir,
+		gtesPerGT:  numGTEsPerGT,
+		grainTable: make(map[uint32][]uint32),
+	}, nil
+}
+
+func (v *vmdkReader) Size() int64 { return v.capacity * vmdkSectorSize }
+
+func (v *vmdkReader) grainTableFor(gtIndex uint32) ([]uint32, error) {
+	if gt, ok := v.grainTable[gtIndex]; ok {
+		return gt, nil
+	}
+	if int(gtIndex) >= len(v.grainDir) {
+		return nil, fmt.Errorf("grain table index %d out of range", gtIndex)
+	}
+	buf := make([]byte, v.gtesPerGT*4)
+	if _, err := v.r.ReadAt(buf, int64(v.grainDir[gtIndex])*vmdkSectorSize); err != nil {
+		return nil, fmt.Errorf("reading VMDK grain table %d: %w", gtIndex, err)
+	}
+	gt := make([]uint32, v.gtesPerGT)
+	for i := range gt {
+		gt[i] = binary.LittleEndian.Uint32(buf[i*4 : i*4+4])
+	}
+	v.grainTable[gtIndex] = gt
+	return gt, nil
+}
+
+func (v *vmdkReader) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	grainBytes := v.grainSize * vmdkSectorSize
+	for total < len(p) {
+		pos := off + int64(total)
+		grainIndex := pos / grainBytes
+		within := pos % grainBytes
+
+		gtIndex := uint32(grainIndex / v.gtesPerGT)
+		gtEntry := int(grainIndex % v.gtesPerGT)
+
+		n := len(p) - total
+		if int64(n) > grainBytes-within {
+			n = int(grainBytes - within)
+		}
+
+		gt, err := v.grainTableFor(gtIndex)
+		if err != nil {
+			return total, err
+		}
+
+		if gtEntry >= len(gt) || gt[gtEntry] == 0 {
+			total += copyZero(p[total:total+n], n)
+			continue
+		}
+
+		grainOffset := int64(gt[gtEntry])*vmdkSectorSize + within
+		read, err := v.r.ReadAt(p[total:total+n], grainOffset)
+		if err != nil {
+			return total, err
+		}
+		total += read
+	}
+	return total, nil
+}
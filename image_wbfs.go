@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// wbfsReader presents the first disc stored in a .wbfs container as a flat image. WBFS splits a disc's
+// payload into fixed-size "WBFS sectors" that are scattered non-contiguously through the file and mapped
+// by a per-disc WLBA table; multi-disc WBFS partitions are not supported, matching the common case of one
+// disc per .wbfs file produced by ripping tools.
+type wbfsReader struct {
+	r              io.ReaderAt
+	wbfsSectorSize int64
+	wlbaTable      []uint16
+	discSize       int64
+}
+
+// wiiDiscSize is the payload size of a standard single-layer Wii/GameCube disc image.
+const wiiDiscSize = 4699979776
+
+func newWBFSReader(r io.ReaderAt, size int64) (ImageReader, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading WBFS header: %w", err)
+	}
+	if string(header[0:4]) != "WBFS" {
+		return nil, fmt.Errorf("not a WBFS image")
+	}
+
+	wbfsSecShift := header[9]
+	wbfsSectorSize := int64(1) << wbfsSecShift
+	if wbfsSectorSize <= 0 {
+		return nil, fmt.Errorf("invalid WBFS sector shift")
+	}
+
+	// Disc 0's info sector (disc header copy + WLBA table) is the first WBFS sector after the header sector.
+	discInfoOffset := wbfsSectorSize
+	entryCount := int((wiiDiscSize + wbfsSectorSize - 1) / wbfsSectorSize)
+
+	tableBytes := make([]byte, entryCount*2)
+	if _, err := r.ReadAt(tableBytes, discInfoOffset+0x100); err != nil {
+		return nil, fmt.Errorf("reading WBFS disc table: %w", err)
+	}
+
+	wlbaTable := make([]uint16, entryCount)
+	for i := range wlbaTable {
+		wlbaTable[i] = binary.BigEndian.Uint16(tableBytes[i*2 : i*2+2])
+	}
+
+	return &wbfsReader{
+		r:              r,
+		wbfsSectorSize: wbfsSectorSize,
+		wlbaTable:      wlbaTable,
+		discSize:       wiiDiscSize,
+	}, nil
+}
+
+func (w *wbfsReader) Size() int64 { return w.discSize }
+
+func (w *wbfsReader) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		pos := off + int64(total)
+		logicalSector := pos / w.wbfsSectorSize
+		within := pos % w.wbfsSectorSize
+
+		if int(logicalSector) >= len(w.wlbaTable) {
+			if total == 0 {
+				return 0, io.EOF
+			}
+			return total, nil
+		}
+
+		n := len(p) - total
+		if int64(n) > w.wbfsSectorSize-within {
+			n = int(w.wbfsSectorSize - within)
+		}
+
+		physicalSector := int64(w.wlbaTable[logicalSector])
+		if physicalSector == 0 {
+			// Unallocated WBFS sectors read back as zeros.
+			total += copyZero(p[total:total+n], n)
+			continue
+		}
+
+		chunk := make([]byte, w.wbfsSectorSize)
+		if _, err := w.r.ReadAt(chunk, physicalSector*w.wbfsSectorSize); err != nil {
+			return total, err
+		}
+		total += copy(p[total:total+n], chunk[within:within+int64(n)])
+	}
+	return total, nil
+}
@@ -1,9 +1,14 @@
+//go:build !js
+
 package main
 
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -51,8 +56,25 @@ type GPTPartition struct {
 }
 
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		runCreate(os.Args[2:])
+		return
+	case "add-partition":
+		runAddPartition(os.Args[2:])
+		return
+	case "delete-partition":
+		runDeletePartition(os.Args[2:])
+		return
+	}
+
 	if len(os.Args) != 2 {
-		fmt.Printf("Usage: %s <disk_image_file>\n", os.Args[0])
+		usage()
 		os.Exit(1)
 	}
 
@@ -64,9 +86,20 @@ func main() {
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Printf("Error stating file: %v\n", err)
+		os.Exit(1)
+	}
+	img, err := OpenImage(file, info.Size())
+	if err != nil {
+		fmt.Printf("Error opening disk image: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Read first sector (MBR)
 	mbr := make([]byte, SECTOR_SIZE)
-	n, err := file.Read(mbr)
+	n, err := img.ReadAt(mbr, 0)
 	if err != nil || n != SECTOR_SIZE {
 		fmt.Printf("Error reading MBR: %v\n", err)
 		os.Exit(1)
@@ -75,6 +108,25 @@ func main() {
 	// Check MBR signature
 	signature := binary.LittleEndian.Uint16(mbr[510:512])
 	if signature != MBR_SIGNATURE {
+		// Not a disk image MBR/GPT; it may be a raw Intel ME firmware dump instead. The $FPT marker
+		// lives well within the start of such dumps, so cap the read instead of loading the whole image.
+		scanSize := img.Size()
+		if scanSize > fptScanWindow {
+			scanSize = fptScanWindow
+		}
+		data := make([]byte, scanSize)
+		n, rerr := img.ReadAt(data, 0)
+		if rerr != nil && rerr != io.EOF {
+			fmt.Println("Invalid MBR signature")
+			os.Exit(1)
+		}
+		if fpt, ferr := parseFPT(data[:n]); ferr == nil && fpt != nil {
+			fmt.Printf("Disk Image: %s\n", filename)
+			fmt.Println("=" + string(make([]byte, len(filename)+12)))
+			fmt.Println("Partition Table Type: IntelME-FPT")
+			printFPTReport(fpt)
+			return
+		}
 		fmt.Println("Invalid MBR signature")
 		os.Exit(1)
 	}
@@ -87,19 +139,201 @@ func main() {
 	if firstPartType == 0xEE {
 		// This is GPT
 		fmt.Println("Partition Table Type: GPT")
-		readGPTPartitions(file)
+		readGPTPartitions(img, mbr)
 	} else {
 		// This is MBR
 		fmt.Println("Partition Table Type: MBR")
-		readMBRPartitions(mbr)
+		readMBRPartitions(img, mbr)
+	}
+}
+
+func usage() {
+	fmt.Printf("Usage: %s <disk_image_file>\n", os.Args[0])
+	fmt.Printf("       %s create <file> <blockSize> <numBlocks>\n", os.Args[0])
+	fmt.Printf("       %s add-partition <file> <typeGUID> <name> <startLBA> <endLBA>\n", os.Args[0])
+	fmt.Printf("       %s delete-partition <file> <index>\n", os.Args[0])
+}
+
+// readDiskFromImage loads an existing GPT disk image's primary copy into a Disk that can be mutated and
+// written back out with WriteGPT.
+func readDiskFromImage(file *os.File) (*Disk, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	blockSize := uint32(SECTOR_SIZE)
+	numBlocks := uint64(info.Size()) / uint64(blockSize)
+	img := &rawImageReader{r: file, size: info.Size()}
+
+	header, headerBytes, err := readGPTHeaderAt(img, 1)
+	if err != nil {
+		return nil, err
+	}
+	entryBytes, err := readGPTEntriesAt(img, header)
+	if err != nil {
+		return nil, err
+	}
+	partitions, _, verr := validateGPTCopy("primary", headerBytes, entryBytes, header)
+	if verr != nil {
+		return nil, verr
+	}
+
+	return &Disk{
+		BlockSize:  blockSize,
+		NumBlocks:  numBlocks,
+		DiskGUID:   header.DiskGUID,
+		Partitions: partitions,
+	}, nil
+}
+
+func runCreate(args []string) {
+	if len(args) != 3 {
+		fmt.Println("Usage: create <file> <blockSize> <numBlocks>")
+		os.Exit(1)
+	}
+
+	blockSize, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid block size: %v\n", err)
+		os.Exit(1)
+	}
+	numBlocks, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		fmt.Printf("Invalid block count: %v\n", err)
+		os.Exit(1)
+	}
+
+	disk, err := NewDisk(uint32(blockSize), numBlocks)
+	if err != nil {
+		fmt.Printf("Error creating disk: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		fmt.Printf("Error creating file: %v\n", err)
+		os.Exit(1)
 	}
+	defer file.Close()
+
+	if err := file.Truncate(int64(numBlocks * blockSize)); err != nil {
+		fmt.Printf("Error sizing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := WriteGPT(file, disk); err != nil {
+		fmt.Printf("Error writing GPT: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Created GPT disk image %s (%d blocks of %d bytes)\n", args[0], numBlocks, blockSize)
 }
 
-func readMBRPartitions(mbr []byte) {
+func runAddPartition(args []string) {
+	if len(args) != 5 {
+		fmt.Println("Usage: add-partition <file> <typeGUID> <name> <startLBA> <endLBA>")
+		os.Exit(1)
+	}
+	filename, guidStr, name := args[0], args[1], args[2]
+
+	startLBA, err := strconv.ParseUint(args[3], 10, 64)
+	if err != nil {
+		fmt.Printf("Invalid start LBA: %v\n", err)
+		os.Exit(1)
+	}
+	endLBA, err := strconv.ParseUint(args[4], 10, 64)
+	if err != nil {
+		fmt.Printf("Invalid end LBA: %v\n", err)
+		os.Exit(1)
+	}
+	typeGUID, err := parseGUID(guidStr)
+	if err != nil {
+		fmt.Printf("Invalid type GUID: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		fmt.Printf("Error opening file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	disk, err := readDiskFromImage(file)
+	if err != nil {
+		fmt.Printf("Error reading disk: %v\n", err)
+		os.Exit(1)
+	}
+
+	part, err := disk.AddPartition(typeGUID, name, startLBA, endLBA)
+	if err != nil {
+		fmt.Printf("Error adding partition: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := WriteGPT(file, disk); err != nil {
+		fmt.Printf("Error writing GPT: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Added partition %q at LBA %d-%d\n", name, part.StartLBA, part.EndLBA)
+}
+
+func runDeletePartition(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: delete-partition <file> <index>")
+		os.Exit(1)
+	}
+	filename := args[0]
+
+	index, err := strconv.Atoi(args[1])
+	if err != nil {
+		fmt.Printf("Invalid index: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		fmt.Printf("Error opening file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	disk, err := readDiskFromImage(file)
+	if err != nil {
+		fmt.Printf("Error reading disk: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := disk.DeletePartition(index - 1); err != nil {
+		fmt.Printf("Error deleting partition: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := WriteGPT(file, disk); err != nil {
+		fmt.Printf("Error writing GPT: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Deleted partition %d\n", index)
+}
+
+// probePartitionFS reads a partition's first sectors from its StartLBA to identify the filesystem it
+// contains, returning FSInfo{Type: "Unknown"} if the probe window can't be read.
+func probePartitionFS(img ImageReader, startLBA uint64) FSInfo {
+	buf := make([]byte, fsProbeWindow)
+	n, err := img.ReadAt(buf, int64(startLBA*SECTOR_SIZE))
+	if err != nil && n == 0 {
+		return FSInfo{Type: "Unknown"}
+	}
+	return probeFS(buf[:n], startLBA)
+}
+
+func readMBRPartitions(img ImageReader, mbr []byte) {
 	fmt.Println("\nPartitions:")
-	fmt.Printf("%-4s %-8s %-12s %-12s %-12s %s\n",
-		"#", "Status", "Type", "Start LBA", "Size", "Description")
-	fmt.Println(string(make([]byte, 70)))
+	fmt.Printf("%-4s %-8s %-12s %-12s %-12s %-12s %s\n",
+		"#", "Status", "Type", "Start LBA", "Size", "Filesystem", "Description")
+	fmt.Println(string(make([]byte, 90)))
 
 	partCount := 0
 	for i := 0; i < 4; i++ {
@@ -119,9 +353,10 @@ func readMBRPartitions(mbr []byte) {
 
 			sizeGB := float64(sizeBlocks*SECTOR_SIZE) / (1024 * 1024 * 1024)
 			typeDesc := getMBRTypeDescription(partType)
+			fsInfo := probePartitionFS(img, uint64(startLBA))
 
-			fmt.Printf("%-4d %-8s 0x%-10X %-12d %-12.2f %s\n",
-				i+1, statusStr, partType, startLBA, sizeGB, typeDesc)
+			fmt.Printf("%-4d %-8s 0x%-10X %-12d %-12.2f %-12s %s\n",
+				i+1, statusStr, partType, startLBA, sizeGB, fsInfo.Type, typeDesc)
 		}
 	}
 
@@ -130,88 +365,156 @@ func readMBRPartitions(mbr []byte) {
 	}
 }
 
-func readGPTPartitions(file *os.File) {
-	// Read GPT header from LBA 1
-	file.Seek(SECTOR_SIZE, 0)
-	headerBytes := make([]byte, 512)
-	n, err := file.Read(headerBytes)
-	if err != nil || n != 512 {
-		fmt.Printf("Error reading GPT header: %v\n", err)
-		return
+// readGPTHeaderAt reads and parses the GPT header sector at the given LBA.
+func readGPTHeaderAt(img ImageReader, lba uint64) (GPTHeader, []byte, error) {
+	headerBytes := make([]byte, SECTOR_SIZE)
+	if _, err := img.ReadAt(headerBytes, int64(lba*SECTOR_SIZE)); err != nil {
+		return GPTHeader{}, nil, fmt.Errorf("reading header at LBA %d: %w", lba, err)
 	}
+	return parseGPTHeader(headerBytes), headerBytes, nil
+}
 
-	// Parse GPT header
-	var header GPTHeader
-	header.Signature = *(*[8]byte)(headerBytes[0:8])
-	header.Revision = binary.LittleEndian.Uint32(headerBytes[8:12])
-	header.HeaderSize = binary.LittleEndian.Uint32(headerBytes[12:16])
-	header.NumPartitions = binary.LittleEndian.Uint32(headerBytes[80:84])
-	header.PartitionEntrySize = binary.LittleEndian.Uint32(headerBytes[84:88])
-	header.PartitionTableLBA = binary.LittleEndian.Uint64(headerBytes[72:80])
-
-	// Verify GPT signature
+// readGPTEntriesAt reads the raw partition entry array described by header, rejecting a corrupt
+// signature or a table size that would overflow or run past the end of the image instead of
+// attempting the allocation.
+func readGPTEntriesAt(img ImageReader, header GPTHeader) ([]byte, error) {
 	if string(header.Signature[:]) != GPT_SIGNATURE {
-		fmt.Println("Invalid GPT signature")
-		return
+		return nil, &ErrInvalidGPT{Issues: []string{"bad GPT signature"}}
 	}
 
-	fmt.Printf("GPT Revision: %d.%d\n", header.Revision>>16, header.Revision&0xFFFF)
-	fmt.Printf("Number of Partitions: %d\n", header.NumPartitions)
+	tableSize := uint64(header.NumPartitions) * uint64(header.PartitionEntrySize)
+	tableOffset := header.PartitionTableLBA * SECTOR_SIZE
+	if tableSize == 0 || tableOffset+tableSize > uint64(img.Size()) {
+		return nil, &ErrInvalidGPT{Issues: []string{"partition table size out of range"}}
+	}
 
-	// Read partition entries
-	file.Seek(int64(header.PartitionTableLBA*SECTOR_SIZE), 0)
+	entryBytes := make([]byte, tableSize)
+	if _, err := img.ReadAt(entryBytes, int64(tableOffset)); err != nil {
+		return nil, fmt.Errorf("reading partition table at LBA %d: %w", header.PartitionTableLBA, err)
+	}
+	return entryBytes, nil
+}
+
+// printGPTValidation prints which GPT copy was used and the CRC32s that were checked against it.
+func printGPTValidation(v *GPTValidation) {
+	fmt.Printf("GPT copy used: %s\n", v.UsedCopy)
+	fmt.Printf("Header CRC32: stored=%08X computed=%08X\n", v.HeaderCRCStored, v.HeaderCRCComputed)
+	fmt.Printf("Partition table CRC32: stored=%08X computed=%08X\n", v.TableCRCStored, v.TableCRCComputed)
+	for _, issue := range v.Issues {
+		fmt.Printf("  ! %s\n", issue)
+	}
+}
+
+// printFPTReport prints an Intel ME Flash Partition Table's header checksum status and its entries.
+func printFPTReport(fpt *FPTInfo) {
+	fmt.Printf("FPT Offset: 0x%X\n", fpt.Offset)
+	fmt.Printf("Header Version: %d  Entry Version: %d\n", fpt.HeaderVersion, fpt.EntryVersion)
+	fmt.Printf("Header Checksum: %s\n", map[bool]string{true: "valid", false: "INVALID"}[fpt.ChecksumValid])
+	fmt.Printf("Number of Entries: %d\n", fpt.NumEntries)
 
 	fmt.Println("\nPartitions:")
-	fmt.Printf("%-4s %-12s %-12s %-12s %s\n",
-		"#", "Start LBA", "End LBA", "Size", "Name")
+	fmt.Printf("%-8s %-12s %-12s %s\n", "Name", "Offset", "Length", "Description")
 	fmt.Println(string(make([]byte, 60)))
+	for _, e := range fpt.Entries {
+		fmt.Printf("%-8s 0x%-10X 0x%-10X %s\n", e.Name, e.Offset, e.Length, e.Description)
+	}
+}
 
-	partCount := 0
-	for i := uint32(0); i < header.NumPartitions; i++ {
-		partBytes := make([]byte, header.PartitionEntrySize)
-		n, err := file.Read(partBytes)
-		if err != nil || uint32(n) != header.PartitionEntrySize {
-			break
-		}
+// printHybridMBRReport prints any hybrid MBR layout detected and the cross-consistency issues found
+// between its entries and the GPT partition array.
+func printHybridMBRReport(mbrEntries [4]MBRPartition, gptPartitions []GPTPartition) {
+	if !isHybridMBR(mbrEntries) {
+		return
+	}
+	fmt.Println("\nHybrid MBR detected")
+	mismatches := crossCheckHybridMBR(mbrEntries, gptPartitions)
+	if len(mismatches) == 0 {
+		fmt.Println("  No cross-consistency issues found")
+		return
+	}
+	for _, m := range mismatches {
+		fmt.Printf("  [%s] %s\n", m.Severity, m.Description)
+		fmt.Printf("    Remediation: %s\n", m.Remediation)
+	}
+}
 
-		// Check if partition entry is used (non-zero type GUID)
-		allZero := true
-		for j := 0; j < 16; j++ {
-			if partBytes[j] != 0 {
-				allZero = false
-				break
-			}
-		}
+func readGPTPartitions(img ImageReader, mbr []byte) {
+	totalSectors := uint64(img.Size() / SECTOR_SIZE)
 
-		if !allZero {
-			partCount++
-			startLBA := binary.LittleEndian.Uint64(partBytes[32:40])
-			endLBA := binary.LittleEndian.Uint64(partBytes[40:48])
-
-			// Convert UTF-16LE name to string (simplified)
-			name := ""
-			for j := 56; j < 56+72; j += 2 {
-				if partBytes[j] == 0 && partBytes[j+1] == 0 {
-					break
-				}
-				if partBytes[j+1] == 0 {
-					name += string(partBytes[j])
-				}
-			}
-			if name == "" {
-				name = "Unnamed"
-			}
+	header, headerBytes, err := readGPTHeaderAt(img, 1)
+	if err != nil {
+		fmt.Printf("Error reading GPT header: %v\n", err)
+		return
+	}
+
+	entryBytes, err := readGPTEntriesAt(img, header)
+	if err != nil {
+		fmt.Printf("Error reading GPT partition table: %v\n", err)
+		return
+	}
 
-			sizeGB := float64((endLBA-startLBA+1)*SECTOR_SIZE) / (1024 * 1024 * 1024)
+	partitions, validation, verr := validateGPTCopy("primary", headerBytes, entryBytes, header)
 
-			fmt.Printf("%-4d %-12d %-12d %-12.2f %s\n",
-				partCount, startLBA, endLBA, sizeGB, name)
+	// On any mismatch, fall back to the backup GPT header at the last LBA of the image.
+	if verr != nil && totalSectors > 0 {
+		if backupHeader, backupHeaderBytes, berr := readGPTHeaderAt(img, totalSectors-1); berr == nil {
+			if backupEntryBytes, eerr := readGPTEntriesAt(img, backupHeader); eerr == nil {
+				if backupPartitions, backupValidation, bverr := validateGPTCopy("backup", backupHeaderBytes, backupEntryBytes, backupHeader); bverr == nil {
+					header, partitions, validation, verr = backupHeader, backupPartitions, backupValidation, nil
+				} else {
+					validation.Issues = append(validation.Issues, backupValidation.Issues...)
+				}
+			}
 		}
 	}
 
-	if partCount == 0 {
+	printGPTValidation(validation)
+	if verr != nil {
+		fmt.Printf("Warning: %v (showing primary copy anyway)\n", verr)
+	}
+
+	fmt.Printf("GPT Revision: %d.%d\n", header.Revision>>16, header.Revision&0xFFFF)
+	fmt.Printf("Number of Partitions: %d\n", header.NumPartitions)
+
+	fmt.Println("\nPartitions:")
+	fmt.Printf("%-4s %-12s %-12s %-12s %-12s %-24s %s\n",
+		"#", "Start LBA", "End LBA", "Size", "Filesystem", "Type", "Name")
+	fmt.Println(string(make([]byte, 94)))
+
+	if len(partitions) == 0 {
 		fmt.Println("No partitions found")
 	}
+
+	for i, p := range partitions {
+		sizeGB := float64((p.EndLBA-p.StartLBA+1)*SECTOR_SIZE) / (1024 * 1024 * 1024)
+		fsInfo := probePartitionFS(img, p.StartLBA)
+		fmt.Printf("%-4d %-12d %-12d %-12.2f %-12s %-24s %s\n",
+			i+1, p.StartLBA, p.EndLBA, sizeGB, fsInfo.Type, gptTypeGUIDDescription(p.TypeGUID), gptPartitionName(p))
+		printGPTAttributes(decodeGPTAttributes(p.Attributes))
+	}
+
+	printHybridMBRReport(parseMBREntries(mbr), partitions)
+}
+
+// printGPTAttributes prints a partition's decoded UEFI attribute bits, if any are set.
+func printGPTAttributes(attrs GPTAttributes) {
+	if !attrs.RequiredPartition && !attrs.NoBlockIOProtocol && !attrs.LegacyBIOSBootable && attrs.TypeSpecific == 0 {
+		return
+	}
+	var flags []string
+	if attrs.RequiredPartition {
+		flags = append(flags, "required-partition")
+	}
+	if attrs.NoBlockIOProtocol {
+		flags = append(flags, "no-block-io")
+	}
+	if attrs.LegacyBIOSBootable {
+		flags = append(flags, "legacy-bios-bootable")
+	}
+	if attrs.TypeSpecific != 0 {
+		flags = append(flags, fmt.Sprintf("type-specific=0x%04X", attrs.TypeSpecific))
+	}
+	fmt.Printf("       attributes: %s\n", strings.Join(flags, ", "))
 }
 
 func getMBRTypeDescription(partType uint8) string {
@@ -1,3 +1,5 @@
+//go:build js && wasm
+
 package main
 
 import (
@@ -49,36 +51,119 @@ type GPTPartition struct {
 }
 
 type PartitionInfo struct {
-	Number      int     `json:"number"`
-	Status      string  `json:"status,omitempty"`
-	Type        string  `json:"type,omitempty"`
-	StartLBA    uint64  `json:"startLBA,omitempty"`
-	EndLBA      uint64  `json:"endLBA,omitempty"`
-	SizeGB      float64 `json:"sizeGB,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Name        string  `json:"name,omitempty"`
-	Info        string  `json:"info,omitempty"`
-	Note        string  `json:"note,omitempty"`
+	Number          int            `json:"number"`
+	Status          string         `json:"status,omitempty"`
+	Type            string         `json:"type,omitempty"`
+	StartLBA        uint64         `json:"startLBA,omitempty"`
+	EndLBA          uint64         `json:"endLBA,omitempty"`
+	SizeGB          float64        `json:"sizeGB,omitempty"`
+	Description     string         `json:"description,omitempty"`
+	Name            string         `json:"name,omitempty"`
+	Info            string         `json:"info,omitempty"`
+	Note            string         `json:"note,omitempty"`
+	Filesystem      string         `json:"filesystem,omitempty"`
+	UUID            string         `json:"uuid,omitempty"`
+	Label           string         `json:"label,omitempty"`
+	BytesPerSector  uint16         `json:"bytesPerSector,omitempty"`
+	TypeGUID        string         `json:"typeGUID,omitempty"`
+	PartitionGUID   string         `json:"partitionGUID,omitempty"`
+	TypeDescription string         `json:"typeDescription,omitempty"`
+	Attributes      *GPTAttributes `json:"attributes,omitempty"`
+}
+
+// probeWindowAt returns up to fsProbeWindow bytes of data starting at the given LBA, for feeding to probeFS.
+func probeWindowAt(data []byte, startLBA uint64) []byte {
+	offset := startLBA * SECTOR_SIZE
+	if offset >= uint64(len(data)) {
+		return nil
+	}
+	end := offset + fsProbeWindow
+	if end > uint64(len(data)) {
+		end = uint64(len(data))
+	}
+	return data[offset:end]
 }
 
 type AnalysisResult struct {
-	Filename    string          `json:"filename"`
-	TableType   string          `json:"tableType"`
-	Partitions  []PartitionInfo `json:"partitions"`
-	Error       string          `json:"error,omitempty"`
-	GPTRevision string          `json:"gptRevision,omitempty"`
+	Filename    string           `json:"filename"`
+	TableType   string           `json:"tableType"`
+	Partitions  []PartitionInfo  `json:"partitions"`
+	Error       string           `json:"error,omitempty"`
+	GPTRevision string           `json:"gptRevision,omitempty"`
+	Validation  *GPTValidation   `json:"validation,omitempty"`
+	HybridMBR   *HybridMBRReport `json:"hybridMBR,omitempty"`
+	FPT         *FPTInfo         `json:"fpt,omitempty"`
 }
 
 func main() {
 	c := make(chan struct{}, 0)
 
-	// Register the function to be called from JavaScript
+	// Register the functions to be called from JavaScript
 	js.Global().Set("analyzeDiskImageGo", js.FuncOf(analyzeDiskImageWrapper))
+	js.Global().Set("createDiskImageGo", js.FuncOf(createDiskImageWrapper))
 
 	fmt.Println("Go WebAssembly initialized")
 	<-c
 }
 
+// memWriterAt is an in-memory io.WriterAt backing a disk image built entirely in the browser, so WriteGPT
+// can run unchanged over it before the result is handed back to JavaScript as a Uint8Array.
+type memWriterAt struct {
+	buf []byte
+}
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	end := int(off) + len(p)
+	if off < 0 || end > len(m.buf) {
+		return 0, fmt.Errorf("write out of bounds: offset %d len %d buffer %d", off, len(p), len(m.buf))
+	}
+	copy(m.buf[off:end], p)
+	return len(p), nil
+}
+
+// createDiskImageWrapper builds a GPT disk image from a blockSize, a numBlocks, and a JS array of
+// {typeGUID, name, startLBA, endLBA} partition descriptors, returning the raw image bytes as a Uint8Array
+// so the browser UI can offer it for download.
+func createDiskImageWrapper(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return map[string]interface{}{
+			"error": "Missing arguments: need blockSize, numBlocks and partitions",
+		}
+	}
+
+	blockSize := uint32(args[0].Int())
+	numBlocks := uint64(args[1].Int())
+	partsJS := args[2]
+
+	disk, err := NewDisk(blockSize, numBlocks)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	for i := 0; i < partsJS.Length(); i++ {
+		entry := partsJS.Index(i)
+		typeGUID, err := parseGUID(entry.Get("typeGUID").String())
+		if err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+		name := entry.Get("name").String()
+		startLBA := uint64(entry.Get("startLBA").Int())
+		endLBA := uint64(entry.Get("endLBA").Int())
+		if _, err := disk.AddPartition(typeGUID, name, startLBA, endLBA); err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+	}
+
+	image := &memWriterAt{buf: make([]byte, numBlocks*uint64(blockSize))}
+	if err := WriteGPT(image, disk); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	jsArray := js.Global().Get("Uint8Array").New(len(image.buf))
+	js.CopyBytesToJS(jsArray, image.buf)
+	return map[string]interface{}{"image": jsArray}
+}
+
 func analyzeDiskImageWrapper(this js.Value, args []js.Value) interface{} {
 	if len(args) < 2 {
 		return map[string]interface{}{
@@ -119,6 +204,12 @@ func analyzeDiskImage(data []byte, filename string) AnalysisResult {
 	// Check MBR signature
 	signature := binary.LittleEndian.Uint16(data[510:512])
 	if signature != MBR_SIGNATURE {
+		// Not a disk image MBR/GPT; it may be a raw Intel ME firmware dump instead.
+		if fpt, err := parseFPT(data); err == nil && fpt != nil {
+			result.TableType = "IntelME-FPT"
+			result.FPT = fpt
+			return result
+		}
 		result.Error = "Invalid MBR signature"
 		return result
 	}
@@ -127,7 +218,7 @@ func analyzeDiskImage(data []byte, filename string) AnalysisResult {
 	firstPartType := data[446+4]
 	if firstPartType == 0xEE {
 		result.TableType = "GPT"
-		result.Partitions = readGPTPartitions(data)
+		result.Partitions, result.Validation, result.HybridMBR = readGPTPartitions(data)
 	} else {
 		result.TableType = "MBR"
 		result.Partitions = readMBRPartitions(data)
@@ -155,14 +246,19 @@ func readMBRPartitions(data []byte) []PartitionInfo {
 
 			sizeGB := float64(sizeBlocks*SECTOR_SIZE) / (1024 * 1024 * 1024)
 			typeDesc := getMBRTypeDescription(partType)
+			fsInfo := probeFS(probeWindowAt(data, uint64(startLBA)), uint64(startLBA))
 
 			partition := PartitionInfo{
-				Number:      i + 1,
-				Status:      statusStr,
-				Type:        fmt.Sprintf("0x%02X", partType),
-				StartLBA:    uint64(startLBA),
-				SizeGB:      sizeGB,
-				Description: typeDesc,
+				Number:         i + 1,
+				Status:         statusStr,
+				Type:           fmt.Sprintf("0x%02X", partType),
+				StartLBA:       uint64(startLBA),
+				SizeGB:         sizeGB,
+				Description:    typeDesc,
+				Filesystem:     fsInfo.Type,
+				UUID:           fsInfo.UUID,
+				Label:          fsInfo.Label,
+				BytesPerSector: fsInfo.BytesPerSector,
 			}
 
 			partitions = append(partitions, partition)
@@ -172,7 +268,26 @@ func readMBRPartitions(data []byte) []PartitionInfo {
 	return partitions
 }
 
-func readGPTPartitions(data []byte) []PartitionInfo {
+// readGPTHeaderAndEntriesAtBytes parses the GPT header at the given LBA within data and the partition
+// entry array it describes, failing if either runs past the end of the available data.
+func readGPTHeaderAndEntriesAtBytes(data []byte, lba uint64) (GPTHeader, []byte, []byte, error) {
+	headerOffset := int(lba) * SECTOR_SIZE
+	if headerOffset+SECTOR_SIZE > len(data) {
+		return GPTHeader{}, nil, nil, fmt.Errorf("not enough data to read header at LBA %d", lba)
+	}
+	headerBytes := data[headerOffset : headerOffset+SECTOR_SIZE]
+	header := parseGPTHeader(headerBytes)
+
+	entryTableOffset := int(header.PartitionTableLBA) * SECTOR_SIZE
+	entrySize := int(header.NumPartitions) * int(header.PartitionEntrySize)
+	if entryTableOffset+entrySize > len(data) || entrySize < 0 {
+		return header, headerBytes, nil, fmt.Errorf("not enough data to read partition table at LBA %d", header.PartitionTableLBA)
+	}
+
+	return header, headerBytes, data[entryTableOffset : entryTableOffset+entrySize], nil
+}
+
+func readGPTPartitions(data []byte) ([]PartitionInfo, *GPTValidation, *HybridMBRReport) {
 	var partitions []PartitionInfo
 
 	// Check if we have enough data for GPT header (need at least 2 sectors)
@@ -182,99 +297,83 @@ func readGPTPartitions(data []byte) []PartitionInfo {
 			Info:   "GPT detected but insufficient data",
 			Note:   "Need at least 1KB of data to read GPT header",
 		})
-		return partitions
+		return partitions, nil, nil
 	}
 
-	// Read GPT header from second sector
-	headerBytes := data[SECTOR_SIZE : SECTOR_SIZE*2]
-
-	// Verify GPT signature
-	if string(headerBytes[0:8]) != GPT_SIGNATURE {
+	header, headerBytes, entryBytes, err := readGPTHeaderAndEntriesAtBytes(data, 1)
+	if err != nil {
 		partitions = append(partitions, PartitionInfo{
 			Number: 1,
-			Info:   "Invalid GPT signature in header",
-			Note:   "GPT structure may be corrupted",
+			Info:   "GPT detected but insufficient data",
+			Note:   err.Error(),
 		})
-		return partitions
+		return partitions, nil, nil
 	}
 
-	// Parse GPT header
-	revision := binary.LittleEndian.Uint32(headerBytes[8:12])
-	numPartitions := binary.LittleEndian.Uint32(headerBytes[80:84])
-	partitionEntrySize := binary.LittleEndian.Uint32(headerBytes[84:88])
-	partitionTableLBA := binary.LittleEndian.Uint64(headerBytes[72:80])
-
-	// Calculate required data size for partition table
-	requiredSize := int(partitionTableLBA*SECTOR_SIZE) + int(numPartitions*partitionEntrySize)
-
-	if len(data) < requiredSize {
+	if string(header.Signature[:]) != GPT_SIGNATURE {
 		partitions = append(partitions, PartitionInfo{
 			Number: 1,
-			Info:   fmt.Sprintf("GPT detected (Rev %d.%d, %d partitions)", revision>>16, revision&0xFFFF, numPartitions),
-			Note:   fmt.Sprintf("Need at least %d bytes to read all partition entries", requiredSize),
+			Info:   "Invalid GPT signature in header",
+			Note:   "GPT structure may be corrupted",
 		})
-		return partitions
+		return partitions, nil, nil
 	}
 
-	// Read partition entries
-	partitionTableOffset := int(partitionTableLBA * SECTOR_SIZE)
-	partCount := 0
-
-	for i := uint32(0); i < numPartitions && partitionTableOffset+int(i*partitionEntrySize)+int(partitionEntrySize) <= len(data); i++ {
-		entryOffset := partitionTableOffset + int(i*partitionEntrySize)
-		partBytes := data[entryOffset : entryOffset+int(partitionEntrySize)]
-
-		// Check if partition entry is used (non-zero type GUID)
-		allZero := true
-		for j := 0; j < 16; j++ {
-			if partBytes[j] != 0 {
-				allZero = false
-				break
-			}
-		}
-
-		if !allZero {
-			partCount++
-			startLBA := binary.LittleEndian.Uint64(partBytes[32:40])
-			endLBA := binary.LittleEndian.Uint64(partBytes[40:48])
-
-			// Convert UTF-16LE name to string (simplified)
-			name := ""
-			for j := 56; j < 56+72 && j+1 < len(partBytes); j += 2 {
-				if partBytes[j] == 0 && partBytes[j+1] == 0 {
-					break
-				}
-				if partBytes[j+1] == 0 && partBytes[j] >= 32 && partBytes[j] <= 126 {
-					name += string(partBytes[j])
+	gptPartitions, validation, verr := validateGPTCopy("primary", headerBytes, entryBytes, header)
+
+	// On any mismatch, fall back to the backup GPT header at the last LBA of the image.
+	if verr != nil {
+		totalSectors := uint64(len(data) / SECTOR_SIZE)
+		if totalSectors > 0 {
+			if backupHeader, backupHeaderBytes, backupEntryBytes, berr := readGPTHeaderAndEntriesAtBytes(data, totalSectors-1); berr == nil {
+				if backupPartitions, backupValidation, bverr := validateGPTCopy("backup", backupHeaderBytes, backupEntryBytes, backupHeader); bverr == nil {
+					header, gptPartitions, validation, verr = backupHeader, backupPartitions, backupValidation, nil
+				} else {
+					validation.Issues = append(validation.Issues, backupValidation.Issues...)
 				}
 			}
-			if name == "" {
-				name = "Unnamed"
-			}
-
-			sizeGB := float64((endLBA-startLBA+1)*SECTOR_SIZE) / (1024 * 1024 * 1024)
-
-			partition := PartitionInfo{
-				Number:   partCount,
-				StartLBA: startLBA,
-				EndLBA:   endLBA,
-				SizeGB:   sizeGB,
-				Name:     name,
-			}
-
-			partitions = append(partitions, partition)
 		}
 	}
 
+	for i, p := range gptPartitions {
+		sizeGB := float64((p.EndLBA-p.StartLBA+1)*SECTOR_SIZE) / (1024 * 1024 * 1024)
+		fsInfo := probeFS(probeWindowAt(data, p.StartLBA), p.StartLBA)
+		attrs := decodeGPTAttributes(p.Attributes)
+		partitions = append(partitions, PartitionInfo{
+			Number:          i + 1,
+			StartLBA:        p.StartLBA,
+			EndLBA:          p.EndLBA,
+			SizeGB:          sizeGB,
+			Name:            gptPartitionName(p),
+			Filesystem:      fsInfo.Type,
+			UUID:            fsInfo.UUID,
+			Label:           fsInfo.Label,
+			BytesPerSector:  fsInfo.BytesPerSector,
+			TypeGUID:        formatGUID(p.TypeGUID),
+			PartitionGUID:   formatGUID(p.PartitionGUID),
+			TypeDescription: gptTypeGUIDDescription(p.TypeGUID),
+			Attributes:      &attrs,
+		})
+	}
+
 	if len(partitions) == 0 {
 		partitions = append(partitions, PartitionInfo{
 			Number: 1,
-			Info:   fmt.Sprintf("GPT structure valid (Rev %d.%d)", revision>>16, revision&0xFFFF),
+			Info:   fmt.Sprintf("GPT structure valid (Rev %d.%d)", header.Revision>>16, header.Revision&0xFFFF),
 			Note:   "No active partitions found in partition table",
 		})
 	}
 
-	return partitions
+	var hybrid *HybridMBRReport
+	mbrEntries := parseMBREntries(data[:SECTOR_SIZE])
+	if isHybridMBR(mbrEntries) {
+		hybrid = &HybridMBRReport{
+			IsHybrid:   true,
+			Mismatches: crossCheckHybridMBR(mbrEntries, gptPartitions),
+		}
+	}
+
+	return partitions, validation, hybrid
 }
 
 func getMBRTypeDescription(partType uint8) string {